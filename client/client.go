@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/riimi/tutorial-grpc-chat/pb"
 	"github.com/zserge/lorca"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 type ChatClient struct {
@@ -22,22 +35,49 @@ type ChatClient struct {
 
 	Connected bool
 	Id        string
+	Room      string
+	token     string
+	lastSeq   uint64
+
+	// stream is the Chat stream of the session, replaced on reconnect. Its
+	// Send is guarded by sendMu as the UI calls in from several goroutines.
+	sendMu sync.Mutex
+	stream pb.ChatService_ChatClient
+
+	accessToken string
+	// user is who we logged in as, which our messages keep as author.
+	user string
+	// nickname and avatarURL are set again on every new session.
+	nickname  string
+	avatarURL string
+	// downloads is the directory attachments are saved in.
+	downloads string
 }
 
-func NewGophersClient(w, h int) *ChatClient {
+const reconnectInterval = 2 * time.Second
+
+var errNotConnected = errors.New("[chat] not connected")
+
+func NewGophersClient(w, h int, room string) *ChatClient {
 	ui, err := lorca.New("", "", w, h)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	return &ChatClient{
-		ui: ui,
+		ui:   ui,
+		Room: room,
 	}
 }
 
-func (c *ChatClient) Connect(addr string) error {
+// Connect dials the chat server, in plaintext when creds is nil.
+func (c *ChatClient) Connect(addr string, creds credentials.TransportCredentials) error {
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithInsecure())
+	if creds != nil {
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
 	conn, err := grpc.Dial(addr, opts...)
 	if err != nil {
 		log.Fatalf("fail to dial: %v", err)
@@ -49,58 +89,523 @@ func (c *ChatClient) Connect(addr string) error {
 	return nil
 }
 
+func (c *ChatClient) Login(username, password string) error {
+	resp, err := c.rpc.Login(context.Background(), &pb.LoginRequest{
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+	c.accessToken = resp.Token
+	c.user = username
+	return nil
+}
+
 func (c *ChatClient) Close() {
 	c.conn.Close()
 }
 
 func (c *ChatClient) Subscribe() {
-	ctx := context.Background()
-	stream, err := c.rpc.Subscribe(ctx, &empty.Empty{})
+	stream, err := c.subscribe(0)
 	if err != nil {
 		log.Printf("[Hello] failed to connect: %v", err)
 		c.PushMessage(err.Error())
 		return
 	}
+	c.restoreProfile()
+	c.backfill()
 
 	go c.readPump(stream)
 }
 
-func (c *ChatClient) readPump(stream pb.ChatService_SubscribeClient) {
+// subscribe opens a Chat stream, replaying the messages after resumeFrom
+// when it is not zero, and picks up the session identity from its header.
+// The token is still needed for the calls outside of the stream.
+func (c *ChatClient) subscribe(resumeFrom uint64) (pb.ChatService_ChatClient, error) {
+	stream, err := c.rpc.Chat(c.context())
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&pb.ChatFrame{Frame: &pb.ChatFrame_Subscribe{Subscribe: &pb.SubscribeRequest{
+		Room:       c.Room,
+		ResumeFrom: resumeFrom,
+	}}}); err != nil {
+		return nil, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return nil, err
+	}
+	ids := header.Get("x-session-id")
+	if len(ids) == 0 {
+		// the server ended the call before accepting the session
+		_, err := stream.Recv()
+		return nil, err
+	}
+	c.Id = ids[0]
+	if tokens := header.Get("x-session-token"); len(tokens) > 0 {
+		c.token = tokens[0]
+	}
+	c.sendMu.Lock()
+	c.stream = stream
+	c.sendMu.Unlock()
+	return stream, nil
+}
+
+// send writes frame to the Chat stream. The server answers a frame it
+// refuses with a notice on the stream.
+func (c *ChatClient) send(frame *pb.ChatFrame) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.stream == nil {
+		return errNotConnected
+	}
+	return c.stream.Send(frame)
+}
+
+func (c *ChatClient) sendMessage(msg *pb.Message) error {
+	return c.send(&pb.ChatFrame{Frame: &pb.ChatFrame_Message{Message: msg}})
+}
+
+// reconnect subscribes again after the stream dropped, resuming from the last
+// received message. When the server no longer has the gap, as after a restart
+// that started its seqs over, it starts over from the history like Subscribe.
+// It gives up, returning nil, when the server turns us away.
+func (c *ChatClient) reconnect() pb.ChatService_ChatClient {
+	for {
+		time.Sleep(reconnectInterval)
+		stream, err := c.subscribe(c.lastSeq)
+		if status.Code(err) == codes.OutOfRange {
+			c.PushMessage("some messages were missed while disconnected")
+			if stream, err = c.subscribe(0); err == nil {
+				c.restoreProfile()
+				c.lastSeq = 0
+				c.backfill()
+				return stream
+			}
+		}
+		if err == nil {
+			c.restoreProfile()
+			return stream
+		}
+		log.Printf("[reconnect] failed to subscribe: %v", err)
+		if status.Code(err) == codes.PermissionDenied {
+			c.PushMessage(status.Convert(err).Message())
+			return nil
+		}
+	}
+}
+
+func (c *ChatClient) readPump(stream pb.ChatService_ChatClient) {
 	for {
 		in, err := stream.Recv()
 		if err == io.EOF {
 			log.Printf("[readpump] recv got EOF: %v", err)
 			c.Close()
 			return
+		} else if status.Code(err) == codes.PermissionDenied {
+			// kicked or banned, coming back right away would not help
+			log.Printf("[readpump] %v", err)
+			c.PushMessage(status.Convert(err).Message())
+			return
 		} else if err != nil {
 			log.Printf("[readpump] failed to recv: %v", err)
+			if stream = c.reconnect(); stream == nil {
+				return
+			}
+			continue
+		}
+		c.receive(in)
+	}
+}
+
+// receive shows msg unless it was already shown by backfill or a replay.
+func (c *ChatClient) receive(msg *pb.Message) {
+	if msg.Seq != 0 {
+		if msg.Seq <= c.lastSeq {
 			return
 		}
-		if c.Id == "" {
-			c.Id = in.Id
+		c.lastSeq = msg.Seq
+	}
+	if msg.GetNotice() != nil {
+		c.pushChat(msg)
+		return
+	}
+	if len(msg.Recipients) > 0 {
+		c.PushDirect(formatDirect(msg))
+		return
+	}
+	switch event := msg.Event.(type) {
+	case *pb.Message_Edit:
+		c.eval("ApplyEdit", `window.app.applyEdit(%s, %s);`, jsString(event.Edit.MessageId), jsString(event.Edit.Text))
+		return
+	case *pb.Message_Delete:
+		c.eval("ApplyDelete", `window.app.applyDelete(%s);`, jsString(event.Delete.MessageId))
+		return
+	case *pb.Message_Typing:
+		if msg.Id != c.Id {
+			c.ShowTyping(msg.Id, displayName(msg), event.Typing.Typing)
+		}
+		return
+	case *pb.Message_Presence:
+		if event.Presence.State == pb.Presence_LEFT {
+			c.ShowTyping(event.Presence.Id, "", false)
+		}
+	}
+	c.pushChat(msg)
+}
+
+func (c *ChatClient) backfill() {
+	resp, err := c.rpc.History(c.context(), &pb.HistoryRequest{Room: c.Room})
+	if err != nil {
+		log.Printf("[backfill] failed to load history: %v", err)
+		return
+	}
+	for _, msg := range resp.Messages {
+		c.receive(msg)
+	}
+}
+
+// context carries the access token from Login and the session token from
+// Subscribe, whichever the client has.
+func (c *ChatClient) context() context.Context {
+	ctx := context.Background()
+	if c.accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.accessToken)
+	}
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-session-token", c.token)
+	}
+	return ctx
+}
+
+// displayName is the nickname of the session msg is from or about, falling
+// back to its id for servers that do not send one.
+func displayName(msg *pb.Message) string {
+	if msg.DisplayName != "" {
+		return msg.DisplayName
+	}
+	return msg.Id
+}
+
+func formatMessage(msg *pb.Message) string {
+	if p := msg.GetPresence(); p != nil {
+		return fmt.Sprintf("[%s] * %s %s", msg.Room, displayName(msg), strings.ToLower(p.State.String()))
+	}
+	if n := msg.GetNicknameChange(); n != nil {
+		old := n.OldNickname
+		if old == "" {
+			old = n.Id
+		}
+		return fmt.Sprintf("* %s is now known as %s", old, n.Nickname)
+	}
+	if m := msg.GetModeration(); m != nil {
+		text := fmt.Sprintf("[%s] * %s was %s by %s", msg.Room, m.Target, moderationVerbs[m.Action], displayName(msg))
+		if m.Until != nil {
+			if until, err := ptypes.Timestamp(m.Until); err == nil {
+				text += " until " + until.Local().Format(time.Kitchen)
+			}
+		}
+		if m.Reason != "" {
+			text += ": " + m.Reason
 		}
+		return text
+	}
+	return fmt.Sprintf("[%s] %s: %s", msg.Room, displayName(msg), msg.Text)
+}
+
+var moderationVerbs = map[pb.Moderation_Action]string{
+	pb.Moderation_KICK:   "kicked",
+	pb.Moderation_BAN:    "banned",
+	pb.Moderation_MUTE:   "muted",
+	pb.Moderation_UNMUTE: "unmuted",
+}
 
-		c.PushMessage(fmt.Sprintf("id: %s, text: %s", in.Id, in.Text))
+// uiMessage is a room message as ui.html renders it. Kind is one of text,
+// markdown, code, attachment, notice and event, Text holding the text, the
+// source or the file name. An attachment is linked to by URL, or by
+// AttachmentId when it was uploaded to the server.
+type uiMessage struct {
+	MessageId    string `json:"messageId"`
+	Own          bool   `json:"own"`
+	Header       string `json:"header"`
+	Kind         string `json:"kind"`
+	Text         string `json:"text"`
+	Language     string `json:"language,omitempty"`
+	URL          string `json:"url,omitempty"`
+	AttachmentId string `json:"attachmentId,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	Edited       bool   `json:"edited"`
+	Deleted      bool   `json:"deleted"`
+}
+
+func toUI(msg *pb.Message) uiMessage {
+	if msg.Event != nil {
+		return uiMessage{Kind: "event", Text: formatMessage(msg)}
+	}
+	m := uiMessage{
+		MessageId: msg.MessageId,
+		Header:    fmt.Sprintf("[%s] %s", msg.Room, displayName(msg)),
+		Kind:      "text",
+		Text:      msg.Text,
+		Edited:    msg.EditedAt != nil,
+		Deleted:   msg.Deleted,
 	}
+	switch content := msg.Content.(type) {
+	case *pb.Message_Markdown:
+		m.Kind, m.Text = "markdown", content.Markdown.Source
+	case *pb.Message_Code:
+		m.Kind, m.Text, m.Language = "code", content.Code.Source, content.Code.Language
+	case *pb.Message_Attachment:
+		m.Kind, m.Text, m.URL, m.Size = "attachment", content.Attachment.Name, content.Attachment.Url, content.Attachment.Size
+		m.AttachmentId = content.Attachment.Id
+	case *pb.Message_Notice:
+		m.Kind, m.Header, m.Text = "notice", "", content.Notice.Text
+	}
+	return m
+}
+
+func formatDirect(msg *pb.Message) string {
+	return fmt.Sprintf("%s -> %s: %s", displayName(msg), strings.Join(msg.Recipients, ", "), msg.Text)
 }
 
 func (c *ChatClient) Send(msg string) {
-	if _, err := c.rpc.Send(context.Background(), &pb.Message{
-		Id:   c.Id,
+	if err := c.sendMessage(&pb.Message{
 		Text: msg,
+		Room: c.Room,
+	}); err != nil {
+		log.Printf("[chat] failed to send message: %v", err)
+	}
+}
+
+// SendContent sends source as markdown or as code in language, depending on
+// kind.
+func (c *ChatClient) SendContent(kind, language, source string) {
+	msg := &pb.Message{
+		Room: c.Room,
+	}
+	switch kind {
+	case "markdown":
+		msg.Content = &pb.Message_Markdown{Markdown: &pb.Markdown{Source: source}}
+	case "code":
+		msg.Content = &pb.Message_Code{Code: &pb.Code{Language: language, Source: source}}
+	default:
+		msg.Text = source
+	}
+	if err := c.sendMessage(msg); err != nil {
+		log.Printf("[chat] failed to send message: %v", err)
+		c.PushMessage(status.Convert(err).Message())
+	}
+}
+
+// SendAttachment uploads the base64 encoded data of a file picked in the UI
+// and sends it to the room.
+func (c *ChatClient) SendAttachment(name, contentType, data string) {
+	content, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		log.Printf("[attachment] failed to decode %s: %v", name, err)
+		return
+	}
+	attachment, err := c.upload(name, contentType, content)
+	if err != nil {
+		log.Printf("[attachment] failed to upload %s: %v", name, err)
+		c.PushMessage(status.Convert(err).Message())
+		return
+	}
+	if err := c.sendMessage(&pb.Message{
+		Room:    c.Room,
+		Content: &pb.Message_Attachment{Attachment: attachment},
 	}); err != nil {
 		log.Printf("[chat] failed to send message: %v", err)
+		c.PushMessage(status.Convert(err).Message())
 	}
 }
 
+const attachmentChunkSize = 32 << 10
+
+func (c *ChatClient) upload(name, contentType string, content []byte) (*pb.Attachment, error) {
+	stream, err := c.rpc.UploadAttachment(c.context())
+	if err != nil {
+		return nil, err
+	}
+	chunk := &pb.AttachmentChunk{Part: &pb.AttachmentChunk_Info{Info: &pb.Attachment{
+		Name:        name,
+		ContentType: contentType,
+	}}}
+	for {
+		// a failed Send is explained by the status from CloseAndRecv
+		if err := stream.Send(chunk); err != nil || len(content) == 0 {
+			break
+		}
+		n := len(content)
+		if n > attachmentChunkSize {
+			n = attachmentChunkSize
+		}
+		chunk = &pb.AttachmentChunk{Part: &pb.AttachmentChunk_Data{Data: content[:n]}}
+		content = content[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+// DownloadAttachment saves the attachment id as name in the downloads
+// directory.
+func (c *ChatClient) DownloadAttachment(id, name string) {
+	path := filepath.Join(c.downloads, filepath.Base(name))
+	if err := c.download(id, path); err != nil {
+		log.Printf("[attachment] failed to download %s: %v", id, err)
+		c.PushMessage(status.Convert(err).Message())
+		return
+	}
+	c.PushMessage("saved " + path)
+}
+
+func (c *ChatClient) download(id, path string) error {
+	stream, err := c.rpc.DownloadAttachment(c.context(), &pb.AttachmentRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return fp.Close()
+		} else if err != nil {
+			os.Remove(path)
+			return err
+		}
+		if _, err := fp.Write(chunk.GetData()); err != nil {
+			os.Remove(path)
+			return err
+		}
+	}
+}
+
+// SendDirect sends msg privately to the comma separated session ids in to.
+func (c *ChatClient) SendDirect(to, msg string) {
+	var recipients []string
+	for _, id := range strings.Split(to, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			recipients = append(recipients, id)
+		}
+	}
+	if err := c.sendMessage(&pb.Message{
+		Text:       msg,
+		Recipients: recipients,
+	}); err != nil {
+		log.Printf("[chat] failed to send direct message: %v", err)
+		c.PushDirect(status.Convert(err).Message())
+	}
+}
+
+// EditMessage replaces the text of one of our messages.
+func (c *ChatClient) EditMessage(messageId, text string) {
+	if err := c.send(&pb.ChatFrame{Frame: &pb.ChatFrame_Edit{Edit: &pb.EditMessageRequest{
+		MessageId: messageId,
+		Text:      text,
+	}}}); err != nil {
+		log.Printf("[chat] failed to edit message: %v", err)
+		c.PushMessage(status.Convert(err).Message())
+	}
+}
+
+func (c *ChatClient) DeleteMessage(messageId string) {
+	if err := c.send(&pb.ChatFrame{Frame: &pb.ChatFrame_Delete{Delete: &pb.DeleteMessageRequest{
+		MessageId: messageId,
+	}}}); err != nil {
+		log.Printf("[chat] failed to delete message: %v", err)
+		c.PushMessage(status.Convert(err).Message())
+	}
+}
+
+// SetTyping starts or stops our typing indicator in the room. The UI starts
+// it again every few seconds while we type, the server stops it on send.
+func (c *ChatClient) SetTyping(typing bool) {
+	if err := c.send(&pb.ChatFrame{Frame: &pb.ChatFrame_Typing{Typing: &pb.TypingRequest{
+		Room:   c.Room,
+		Typing: typing,
+	}}}); err != nil {
+		log.Printf("[chat] failed to set typing: %v", err)
+	}
+}
+
+// SetNickname changes the nickname of the session and keeps it for the
+// sessions after a reconnect. It stays a unary call as only its reply tells
+// whether the nickname is worth keeping.
+func (c *ChatClient) SetNickname(nickname string) {
+	if _, err := c.rpc.SetNickname(c.context(), &pb.SetNicknameRequest{
+		Id:        c.Id,
+		Nickname:  nickname,
+		AvatarUrl: c.avatarURL,
+	}); err != nil {
+		log.Printf("[profile] failed to set nickname: %v", err)
+		c.PushMessage(status.Convert(err).Message())
+		return
+	}
+	c.nickname = nickname
+}
+
+func (c *ChatClient) restoreProfile() {
+	if c.nickname != "" {
+		c.SetNickname(c.nickname)
+	}
+}
+
+// pushChat shows a room message, which the UI lets us edit and delete when
+// we sent it, in this session or as the same author in an earlier one.
+func (c *ChatClient) pushChat(msg *pb.Message) {
+	m := toUI(msg)
+	own := msg.Id == c.Id
+	if msg.Author != "" {
+		own = msg.Author == c.user || msg.Author == c.Id
+	}
+	m.Own = own && msg.MessageId != "" && !msg.Deleted
+	c.pushUI(m)
+}
+
+func (c *ChatClient) pushUI(m uiMessage) {
+	js, _ := json.Marshal(m)
+	c.eval("PushMessage", `window.app.pushMessage(%s);`, js)
+}
+
+// eval runs a call into ui.html, logging its failure under name.
+func (c *ChatClient) eval(name, format string, args ...interface{}) {
+	if err := c.ui.Eval(fmt.Sprintf(format, args...)).Err(); err != nil {
+		log.Printf("[%s] %v", name, err)
+	}
+}
+
+func (c *ChatClient) ShowTyping(id, name string, typing bool) {
+	if err := c.ui.Eval(fmt.Sprintf(`
+        window.app.showTyping(%s, %s, %t);
+	`, jsString(id), jsString(name), typing)).Err(); err != nil {
+		log.Printf("[ShowTyping] %v, %s", err, id)
+	}
+}
+
+// PushMessage shows a notice of the client itself.
 func (c *ChatClient) PushMessage(msg string) {
+	c.pushUI(uiMessage{Kind: "notice", Text: msg})
+}
+
+func (c *ChatClient) PushDirect(msg string) {
 	if err := c.ui.Eval(fmt.Sprintf(`
-        window.app.pushMessage('%s');
-	`, msg)).Err(); err != nil {
-		log.Printf("[PushMessage] %v, %s", err, msg)
+        window.app.pushDirect(%s);
+	`, jsString(msg))).Err(); err != nil {
+		log.Printf("[PushDirect] %v, %s", err, msg)
 	}
 }
 
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
 func (c *ChatClient) Run() {
 	if c.conn == nil {
 		return
@@ -112,6 +617,30 @@ func (c *ChatClient) Run() {
 	if err := c.ui.Bind("send", c.Send); err != nil {
 		log.Fatal(err)
 	}
+	if err := c.ui.Bind("sendContent", c.SendContent); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("sendAttachment", c.SendAttachment); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("downloadAttachment", c.DownloadAttachment); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("sendDirect", c.SendDirect); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("setNickname", c.SetNickname); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("setTyping", c.SetTyping); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("editMessage", c.EditMessage); err != nil {
+		log.Fatal(err)
+	}
+	if err := c.ui.Bind("deleteMessage", c.DeleteMessage); err != nil {
+		log.Fatal(err)
+	}
 
 	fp, err := os.Open("ui.html")
 	if err != nil {
@@ -128,15 +657,66 @@ func (c *ChatClient) Run() {
 	<-c.ui.Done()
 }
 
+// transportCredentials loads the CA to verify the server with and, for mutual
+// TLS, the client certificate. It returns nil when caFile is empty.
+func transportCredentials(caFile, certFile, keyFile, serverName string) (credentials.TransportCredentials, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificate found in " + caFile)
+	}
+	cfg := &tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
 func main() {
 	width := flag.Int("width", 800, "window size width")
 	height := flag.Int("height", 450, "window size height")
 	serverAddr := flag.String("addr", "localhost:40040", "grpc server address")
+	room := flag.String("room", "lobby", "chat room to join")
+	caFile := flag.String("ca", "", "CA file to verify the server certificate with, dials plaintext when empty")
+	certFile := flag.String("cert", "", "client certificate file for mutual TLS")
+	keyFile := flag.String("key", "", "client private key file for mutual TLS")
+	serverName := flag.String("server-name", "", "overrides the server name expected in the server certificate")
+	username := flag.String("user", "", "user name to log in with, when the server requires it")
+	password := flag.String("password", "", "password to log in with")
+	nickname := flag.String("nickname", "", "nickname to chat under")
+	avatar := flag.String("avatar", "", "avatar image url shown next to your messages")
+	downloads := flag.String("downloads", ".", "directory to save attachments in")
 	flag.Parse()
 
-	gophers := NewGophersClient(*width, *height)
-	if err := gophers.Connect(*serverAddr); err != nil {
+	creds, err := transportCredentials(*caFile, *certFile, *keyFile, *serverName)
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	gophers := NewGophersClient(*width, *height, *room)
+	gophers.nickname = *nickname
+	gophers.avatarURL = *avatar
+	gophers.downloads = *downloads
+	if err := gophers.Connect(*serverAddr, creds); err != nil {
+		log.Fatal(err)
+	}
+	if *username != "" {
+		if err := gophers.Login(*username, *password); err != nil {
+			log.Fatal(err)
+		}
+	}
 	gophers.Run()
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	gw "github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	attachmentsPath     = "/v1/chatserver/attachments"
+	attachmentChunkSize = 32 << 10
+)
+
+// attachmentHandler serves the attachment RPCs, which the generated gateway
+// does not handle as they stream raw bytes: POST attachmentsPath takes a
+// multipart form with a "file" part, GET attachmentsPath/{id} returns the
+// content, named after the optional name query parameter. Downloads are never
+// shown inline: an uploaded page would run with the origin of the chat UI.
+type attachmentHandler struct {
+	mux    *runtime.ServeMux
+	client gw.ChatServiceClient
+}
+
+func (h *attachmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, attachmentsPath), "/")
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		h.upload(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		h.download(w, r, id)
+	default:
+		h.error(w, r, status.Error(codes.Unimplemented, http.StatusText(http.StatusNotImplemented)))
+	}
+}
+
+func (h *attachmentHandler) upload(w http.ResponseWriter, r *http.Request) {
+	ctx, err := runtime.AnnotateContext(r.Context(), h.mux, r)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		h.error(w, r, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	var part io.Reader
+	info := &gw.Attachment{}
+	for part == nil {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			h.error(w, r, status.Error(codes.InvalidArgument, "missing file part"))
+			return
+		} else if err != nil {
+			h.error(w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		if p.FormName() == "file" {
+			part = p
+			info.Name = p.FileName()
+			info.ContentType = p.Header.Get("Content-Type")
+		}
+	}
+
+	stream, err := h.client.UploadAttachment(ctx)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	err = stream.Send(&gw.AttachmentChunk{Part: &gw.AttachmentChunk_Info{Info: info}})
+	buf := make([]byte, attachmentChunkSize)
+	for err == nil {
+		var n int
+		n, err = part.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&gw.AttachmentChunk{Part: &gw.AttachmentChunk_Data{Data: buf[:n]}}); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil && err != io.EOF {
+		// a failed Send is explained by the status from CloseAndRecv
+		if _, ok := status.FromError(err); !ok {
+			h.error(w, r, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+	}
+	attachment, err := stream.CloseAndRecv()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	_, outbound := runtime.MarshalerForRequest(h.mux, r)
+	body, err := outbound.Marshal(attachment)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", outbound.ContentType())
+	w.Write(body)
+}
+
+func (h *attachmentHandler) download(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, err := runtime.AnnotateContext(r.Context(), h.mux, r)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	stream, err := h.client.DownloadAttachment(ctx, &gw.AttachmentRequest{Id: id})
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	info := first.GetInfo()
+	if info == nil {
+		h.error(w, r, status.Error(codes.Internal, "missing attachment info"))
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = id
+	}
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name})
+	if disposition == "" {
+		// a name that cannot be encoded is left to the browser
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			// the status line is gone, all that is left is cutting the body short
+			panic(http.ErrAbortHandler)
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return
+		}
+	}
+}
+
+func (h *attachmentHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	_, outbound := runtime.MarshalerForRequest(h.mux, r)
+	runtime.HTTPError(r.Context(), h.mux, outbound, w, r, err)
+}
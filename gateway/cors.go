@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// corsPolicy lets pages from other origins call the gateway, answering
+// preflight requests itself. An origin of "*" allows every origin.
+type corsPolicy struct {
+	origins []string
+}
+
+func newCORSPolicy(origins string) *corsPolicy {
+	p := &corsPolicy{}
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			p.origins = append(p.origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	return p
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	for _, o := range p.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin is the WebSocket counterpart of the CORS headers: it accepts
+// same origin requests and those from allowed origins.
+func (p *corsPolicy) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.allowed(origin)
+}
+
+func (p *corsPolicy) wrap(h http.Handler) http.Handler {
+	if len(p.origins) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !p.allowed(origin) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Expose-Headers", "Grpc-Metadata-X-Session-Id, Grpc-Metadata-X-Session-Token, Grpc-Trailer-Retry-After, Content-Disposition")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE")
+			header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Session-Token, Last-Event-ID")
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
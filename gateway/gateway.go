@@ -2,29 +2,115 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	gw "github.com/riimi/tutorial-grpc-chat/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
 var (
 	EndPoint = flag.String("endpoint", "localhost:40040", "endpoint of chatserver")
 	port     = flag.Int("port", 8081, "gateway port")
+
+	heartbeat   = flag.Duration("heartbeat", 15*time.Second, "interval of the heartbeats on event streams and WebSockets")
+	corsOrigins = flag.String("cors-origins", "", "comma separated origins allowed to call the gateway from the browser, * for any")
+
+	certFile = flag.String("cert", "", "TLS certificate file, serves HTTPS when set")
+	keyFile  = flag.String("key", "", "TLS private key file")
+
+	backendCA   = flag.String("backend-ca", "", "CA file to verify the chatserver certificate with, dials plaintext when empty")
+	backendCert = flag.String("backend-cert", "", "client certificate file presented to the chatserver, list its common name in -gateway-identities of a chatserver with -cert-identity")
+	backendKey  = flag.String("backend-key", "", "client private key file presented to the chatserver")
+	backendName = flag.String("backend-name", "", "overrides the server name expected in the chatserver certificate")
 )
 
+// headerMatcher additionally accepts a plain X-Session-Token header so browser
+// clients do not need the Grpc-Metadata- prefix. The Authorization header
+// carrying the bearer token from Login is forwarded by the runtime itself.
+func headerMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, "X-Session-Token") {
+		return "x-session-token", true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// backendCredentials builds the dial option for the chatserver from the
+// -backend-* flags.
+func backendCredentials() (grpc.DialOption, error) {
+	if *backendCA == "" {
+		return grpc.WithInsecure(), nil
+	}
+	pem, err := ioutil.ReadFile(*backendCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificate found in " + *backendCA)
+	}
+	cfg := &tls.Config{
+		RootCAs:    pool,
+		ServerName: *backendName,
+	}
+	if *backendCert != "" {
+		cert, err := tls.LoadX509KeyPair(*backendCert, *backendKey)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(cfg)), nil
+}
+
 func main() {
 	flag.Parse()
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	mux := runtime.NewServeMux()
-	opts := []grpc.DialOption{grpc.WithInsecure()}
-	if err := gw.RegisterChatServiceHandlerFromEndpoint(ctx, mux, *EndPoint, opts); err != nil {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(headerMatcher))
+	creds, err := backendCredentials()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []grpc.DialOption{creds}
+	conn, err := grpc.DialContext(ctx, *EndPoint, opts...)
+	if err != nil {
 		log.Fatal(err)
 	}
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), mux))
+	defer conn.Close()
+	if err := gw.RegisterChatServiceHandler(ctx, mux, conn); err != nil {
+		log.Fatal(err)
+	}
+	client := gw.NewChatServiceClient(conn)
+	attachments := &attachmentHandler{mux: mux, client: client}
+	cors := newCORSPolicy(*corsOrigins)
+	streams := &streamHandler{mux: mux, client: client, heartbeat: *heartbeat}
+	streams.upgrader.CheckOrigin = cors.checkOrigin
+	backend := newBackendMonitor(conn)
+	go backend.watch(ctx)
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", backend.ServeHealthz)
+	root.HandleFunc("/readyz", backend.ServeReadyz)
+	root.Handle(attachmentsPath, attachments)
+	root.Handle(attachmentsPath+"/", attachments)
+	root.HandleFunc(eventsPath, streams.ServeEvents)
+	root.HandleFunc(wsPath, streams.ServeWebSocket)
+	root.Handle("/v1/", mux)
+	root.Handle("/", webHandler())
+
+	addr := fmt.Sprintf(":%d", *port)
+	if *certFile != "" {
+		log.Fatal(http.ListenAndServeTLS(addr, *certFile, *keyFile, cors.wrap(root)))
+	}
+	log.Fatal(http.ListenAndServe(addr, cors.wrap(root)))
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// backendService is the service the chat server reports health for.
+	backendService = "pb.chatService"
+	readyTimeout   = time.Second
+)
+
+// backendMonitor reports on the connection to the chat server: /healthz
+// answers as long as the gateway runs, /readyz only while the chat server is
+// reachable and serving.
+type backendMonitor struct {
+	conn   *grpc.ClientConn
+	health healthpb.HealthClient
+}
+
+func newBackendMonitor(conn *grpc.ClientConn) *backendMonitor {
+	return &backendMonitor{conn: conn, health: healthpb.NewHealthClient(conn)}
+}
+
+// watch logs every change of the connection state until ctx is done.
+func (m *backendMonitor) watch(ctx context.Context) {
+	state := m.conn.GetState()
+	for {
+		log.Printf("[backend] connection is %s", state)
+		if !m.conn.WaitForStateChange(ctx, state) {
+			return
+		}
+		state = m.conn.GetState()
+	}
+}
+
+type readiness struct {
+	Status     string `json:"status"`
+	Connection string `json:"connection"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (m *backendMonitor) ServeHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok\n"))
+}
+
+func (m *backendMonitor) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	ready := readiness{Status: healthpb.HealthCheckResponse_UNKNOWN.String()}
+	resp, err := m.health.Check(ctx, &healthpb.HealthCheckRequest{Service: backendService})
+	if err != nil {
+		ready.Error = status.Convert(err).Message()
+	} else {
+		ready.Status = resp.Status.String()
+	}
+	// read after the check, which may have woken up an idle connection
+	state := m.conn.GetState()
+	ready.Connection = state.String()
+
+	code := http.StatusOK
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING || state != connectivity.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(ready)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	gw "github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	eventsPath = "/v1/chatserver/events"
+	wsPath     = "/v1/chatserver/ws"
+	wsTimeout  = 10 * time.Second
+)
+
+// streamHandler bridges the chat streams to what browsers speak natively:
+//
+// GET eventsPath serves Subscribe as text/event-stream. A "session" event
+// carries the id and token for the REST calls, every message follows as a
+// "message" event with its seq as event id, so that EventSource resumes
+// where it left off on its own. A failed subscription ends with an "error"
+// event.
+//
+// GET wsPath serves Chat over a WebSocket. The client sends ChatFrame JSON,
+// the subscribe frame being made from the query, and receives
+// {"session": ...}, {"result": Message} and a final {"error": ...} before
+// the close. A subscription the chat server refuses is answered the same way
+// rather than with an HTTP status, which scripts in browsers never see: the
+// close code is 1008 when coming back would not help, such as after a ban,
+// and the error tells a resume_from the server no longer has apart.
+//
+// Both take room and resume_from from the query. As browsers cannot set
+// headers on either, an access_token query parameter stands in for the
+// Authorization header.
+type streamHandler struct {
+	mux       *runtime.ServeMux
+	client    gw.ChatServiceClient
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+}
+
+// session is the first thing sent on a stream.
+type session struct {
+	Id    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// streamError is the last thing sent on a stream that failed.
+type streamError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func newStreamError(err error) streamError {
+	st := status.Convert(err)
+	return streamError{Code: int(st.Code()), Message: st.Message()}
+}
+
+// closeMessage is the close frame of a WebSocket whose stream failed with
+// err: the client may try again later unless it was turned away.
+func closeMessage(err error) []byte {
+	code := websocket.ClosePolicyViolation
+	switch status.Code(err) {
+	case codes.Unavailable:
+		code = websocket.CloseGoingAway
+	case codes.ResourceExhausted:
+		code = websocket.CloseTryAgainLater
+	}
+	// the reason has to fit in a control frame with the code
+	reason := status.Convert(err).Message()
+	for len(reason) > 123 || !utf8.ValidString(reason) {
+		reason = reason[:len(reason)-1]
+	}
+	return websocket.FormatCloseMessage(code, reason)
+}
+
+// streamContext carries the headers of r, and the access_token parameter,
+// to the chat server.
+func (h *streamHandler) streamContext(r *http.Request) (context.Context, error) {
+	ctx, err := runtime.AnnotateContext(r.Context(), h.mux, r)
+	if err != nil {
+		return nil, err
+	}
+	if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	return ctx, nil
+}
+
+// subscribeRequest reads the room and the seq to resume after from the query,
+// or from the Last-Event-ID of a reconnecting EventSource.
+func subscribeRequest(r *http.Request) (*gw.SubscribeRequest, error) {
+	req := &gw.SubscribeRequest{Room: r.URL.Query().Get("room")}
+	resume := r.URL.Query().Get("resume_from")
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		resume = id
+	}
+	if resume != "" {
+		seq, err := strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "resume_from must be a seq")
+		}
+		req.ResumeFrom = seq
+	}
+	return req, nil
+}
+
+func sessionOf(header metadata.MD) session {
+	var sess session
+	if ids := header.Get("x-session-id"); len(ids) > 0 {
+		sess.Id = ids[0]
+	}
+	if tokens := header.Get("x-session-token"); len(tokens) > 0 {
+		sess.Token = tokens[0]
+	}
+	return sess
+}
+
+func (h *streamHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	_, outbound := runtime.MarshalerForRequest(h.mux, r)
+	runtime.HTTPError(r.Context(), h.mux, outbound, w, r, err)
+}
+
+func (h *streamHandler) ServeEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.error(w, r, status.Error(codes.Unimplemented, "streaming unsupported"))
+		return
+	}
+	req, err := subscribeRequest(r)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	ctx, err := h.streamContext(r)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	// cancelled as soon as the browser goes away, which ends the session
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := h.client.Subscribe(ctx, req)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	header, err := stream.Header()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	if _, ok := header["x-session-id"]; !ok {
+		// the server ended the call before accepting the session
+		_, err := stream.Recv()
+		h.error(w, r, err)
+		return
+	}
+
+	_, outbound := runtime.MarshalerForRequest(h.mux, r)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var mu sync.Mutex
+	event := func(format string, args ...interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	data, _ := outbound.Marshal(sessionOf(header))
+	if err := event("event: session\ndata: %s\n\n", data); err != nil {
+		return
+	}
+	go h.beat(ctx, cancel, func() error {
+		return event(": heartbeat\n\n")
+	})
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return
+		} else if err != nil {
+			data, _ := outbound.Marshal(newStreamError(err))
+			event("event: error\ndata: %s\n\n", data)
+			return
+		}
+		data, err := outbound.Marshal(msg)
+		if err != nil {
+			return
+		}
+		if msg.Seq != 0 {
+			err = event("event: message\nid: %d\ndata: %s\n\n", msg.Seq, data)
+		} else {
+			err = event("event: message\ndata: %s\n\n", data)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// beat calls ping every heartbeat until ctx is done, cancelling it when ping
+// fails as the client is gone.
+func (h *streamHandler) beat(ctx context.Context, cancel context.CancelFunc, ping func() error) {
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				cancel()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *streamHandler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	// checked before the session is opened, the upgrader checks again
+	if !websocket.IsWebSocketUpgrade(r) {
+		h.error(w, r, status.Error(codes.InvalidArgument, "not a websocket handshake"))
+		return
+	}
+	if h.upgrader.CheckOrigin != nil && !h.upgrader.CheckOrigin(r) {
+		h.error(w, r, status.Error(codes.PermissionDenied, "origin not allowed"))
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stream, header, err := h.openChat(ctx, r)
+	if err != nil {
+		h.refuse(w, r, err)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has answered already
+		return
+	}
+	defer conn.Close()
+
+	inbound, outbound := runtime.MarshalerForRequest(h.mux, r)
+	var mu sync.Mutex
+	write := func(messageType int, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+		return conn.WriteMessage(messageType, data)
+	}
+	send := func(v interface{}) error {
+		data, err := outbound.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return write(websocket.TextMessage, data)
+	}
+	if err := send(map[string]session{"session": sessionOf(header)}); err != nil {
+		return
+	}
+
+	// a client that answers no ping for two heartbeats is gone
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go h.beat(ctx, cancel, func() error {
+		return write(websocket.PingMessage, nil)
+	})
+	go h.readFrames(ctx, cancel, conn, stream, inbound)
+
+	for {
+		msg, err := stream.Recv()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == io.EOF {
+			write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		} else if err != nil {
+			send(map[string]streamError{"error": newStreamError(err)})
+			write(websocket.CloseMessage, closeMessage(err))
+			return
+		}
+		if err := send(map[string]*gw.Message{"result": msg}); err != nil {
+			return
+		}
+	}
+}
+
+// openChat opens the Chat stream of a WebSocket and subscribes it with the
+// query of r, returning the header carrying the session.
+func (h *streamHandler) openChat(ctx context.Context, r *http.Request) (gw.ChatService_ChatClient, metadata.MD, error) {
+	req, err := subscribeRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err = h.streamContext(r.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	stream, err := h.client.Chat(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := stream.Send(&gw.ChatFrame{Frame: &gw.ChatFrame_Subscribe{Subscribe: req}}); err != nil {
+		return nil, nil, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := header["x-session-id"]; !ok {
+		// the server ended the call before accepting the session
+		_, err := stream.Recv()
+		return nil, nil, err
+	}
+	return stream, header, nil
+}
+
+// refuse completes the handshake only to pass err on and close the socket.
+func (h *streamHandler) refuse(w http.ResponseWriter, r *http.Request, err error) {
+	conn, uerr := h.upgrader.Upgrade(w, r, nil)
+	if uerr != nil {
+		// the upgrader has answered already
+		return
+	}
+	defer conn.Close()
+	_, outbound := runtime.MarshalerForRequest(h.mux, r)
+	data, merr := outbound.Marshal(map[string]streamError{"error": newStreamError(err)})
+	if merr != nil {
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(wsTimeout))
+	if conn.WriteMessage(websocket.TextMessage, data) == nil {
+		conn.WriteMessage(websocket.CloseMessage, closeMessage(err))
+	}
+}
+
+// readFrames passes the frames the client sends on to stream until the
+// client closes the socket, then half-closes stream so the session ends.
+func (h *streamHandler) readFrames(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, stream gw.ChatService_ChatClient, inbound runtime.Marshaler) {
+	defer stream.CloseSend()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				cancel()
+			}
+			return
+		}
+		frame := &gw.ChatFrame{}
+		if err := inbound.Unmarshal(data, frame); err != nil {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseUnsupportedData, err.Error()), time.Now().Add(wsTimeout))
+			cancel()
+			return
+		}
+		if err := stream.Send(frame); err != nil {
+			return
+		}
+	}
+}
@@ -1,13 +1,18 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: chat-gateway.proto
 
+// Schema version 2, see SchemaVersion. Keep chat.proto and chat-gateway.proto
+// in step.
+
 package pb
 
 import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
 	math "math"
@@ -24,9 +29,208 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type SchemaVersion int32
+
+const (
+	SchemaVersion_SCHEMA_UNKNOWN SchemaVersion = 0
+	// SCHEMA_V1 messages only have text.
+	SchemaVersion_SCHEMA_V1 SchemaVersion = 1
+	// SCHEMA_V2 adds content.
+	SchemaVersion_SCHEMA_V2 SchemaVersion = 2
+)
+
+var SchemaVersion_name = map[int32]string{
+	0: "SCHEMA_UNKNOWN",
+	1: "SCHEMA_V1",
+	2: "SCHEMA_V2",
+}
+
+var SchemaVersion_value = map[string]int32{
+	"SCHEMA_UNKNOWN": 0,
+	"SCHEMA_V1":      1,
+	"SCHEMA_V2":      2,
+}
+
+func (x SchemaVersion) String() string {
+	return proto.EnumName(SchemaVersion_name, int32(x))
+}
+
+func (SchemaVersion) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{0}
+}
+
+// Role decides what a session may do to others. Roles are given to user
+// names, or to certificate identities, by the server configuration.
+type Role int32
+
+const (
+	Role_MEMBER    Role = 0
+	Role_MODERATOR Role = 1
+	Role_ADMIN     Role = 2
+)
+
+var Role_name = map[int32]string{
+	0: "MEMBER",
+	1: "MODERATOR",
+	2: "ADMIN",
+}
+
+var Role_value = map[string]int32{
+	"MEMBER":    0,
+	"MODERATOR": 1,
+	"ADMIN":     2,
+}
+
+func (x Role) String() string {
+	return proto.EnumName(Role_name, int32(x))
+}
+
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{1}
+}
+
+type Notice_Level int32
+
+const (
+	Notice_INFO    Notice_Level = 0
+	Notice_WARNING Notice_Level = 1
+)
+
+var Notice_Level_name = map[int32]string{
+	0: "INFO",
+	1: "WARNING",
+}
+
+var Notice_Level_value = map[string]int32{
+	"INFO":    0,
+	"WARNING": 1,
+}
+
+func (x Notice_Level) String() string {
+	return proto.EnumName(Notice_Level_name, int32(x))
+}
+
+func (Notice_Level) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{6, 0}
+}
+
+type Presence_State int32
+
+const (
+	Presence_UNKNOWN Presence_State = 0
+	Presence_JOINED  Presence_State = 1
+	Presence_LEFT    Presence_State = 2
+	Presence_IDLE    Presence_State = 3
+	Presence_ACTIVE  Presence_State = 4
+)
+
+var Presence_State_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "JOINED",
+	2: "LEFT",
+	3: "IDLE",
+	4: "ACTIVE",
+}
+
+var Presence_State_value = map[string]int32{
+	"UNKNOWN": 0,
+	"JOINED":  1,
+	"LEFT":    2,
+	"IDLE":    3,
+	"ACTIVE":  4,
+}
+
+func (x Presence_State) String() string {
+	return proto.EnumName(Presence_State_name, int32(x))
+}
+
+func (Presence_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{7, 0}
+}
+
+type Moderation_Action int32
+
+const (
+	Moderation_NONE   Moderation_Action = 0
+	Moderation_KICK   Moderation_Action = 1
+	Moderation_BAN    Moderation_Action = 2
+	Moderation_MUTE   Moderation_Action = 3
+	Moderation_UNMUTE Moderation_Action = 4
+)
+
+var Moderation_Action_name = map[int32]string{
+	0: "NONE",
+	1: "KICK",
+	2: "BAN",
+	3: "MUTE",
+	4: "UNMUTE",
+}
+
+var Moderation_Action_value = map[string]int32{
+	"NONE":   0,
+	"KICK":   1,
+	"BAN":    2,
+	"MUTE":   3,
+	"UNMUTE": 4,
+}
+
+func (x Moderation_Action) String() string {
+	return proto.EnumName(Moderation_Action_name, int32(x))
+}
+
+func (Moderation_Action) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{20, 0}
+}
+
 type Message struct {
-	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Text                 string   `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// text is the plain text of the message. With a content it is the
+	// fallback shown by clients that do not know the kind; the server fills
+	// it in when it is empty.
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Room string `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	// seq and timestamp are assigned by the server when the message is broadcast.
+	Seq       uint64               `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// recipients makes the message private to the listed session ids; it is
+	// then delivered to them and echoed to the sender only, whatever the room.
+	Recipients []string `protobuf:"bytes,6,rep,name=recipients,proto3" json:"recipients,omitempty"`
+	// event is set on messages generated by the server instead of a text.
+	//
+	// Types that are valid to be assigned to Event:
+	//	*Message_Presence
+	//	*Message_NicknameChange
+	//	*Message_Edit
+	//	*Message_Delete
+	//	*Message_Typing
+	//	*Message_Moderation
+	Event isMessage_Event `protobuf_oneof:"event"`
+	// display_name and avatar_url come from the profile of the session the
+	// message is from, or is about for an event.
+	DisplayName string `protobuf:"bytes,8,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	AvatarUrl   string `protobuf:"bytes,9,opt,name=avatar_url,json=avatarUrl,proto3" json:"avatar_url,omitempty"`
+	// message_id is assigned by the server when the message is sent; it is
+	// the same on every node, unlike seq.
+	MessageId string `protobuf:"bytes,11,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	// edited_at is set once the sender edited the text, deleted once the
+	// sender deleted it, leaving the message without a text in the history.
+	EditedAt *timestamp.Timestamp `protobuf:"bytes,12,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	Deleted  bool                 `protobuf:"varint,13,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// content makes the message more than plain text. Only the server sends
+	// notices.
+	//
+	// Types that are valid to be assigned to Content:
+	//	*Message_Markdown
+	//	*Message_Code
+	//	*Message_Attachment
+	//	*Message_Notice
+	Content isMessage_Content `protobuf_oneof:"content"`
+	// schema is the version the server broadcast the message with.
+	Schema SchemaVersion `protobuf:"varint,17,opt,name=schema,proto3,enum=pb.SchemaVersion" json:"schema,omitempty"`
+	// author is who sent the message for good: the user name or certificate
+	// identity of the sender, else its session id. Only sessions of the same
+	// author may edit or delete the message.
+	Author               string   `protobuf:"bytes,23,opt,name=author,proto3" json:"author,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -71,160 +275,3133 @@ func (m *Message) GetText() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*Message)(nil), "pb.Message")
+func (m *Message) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
 }
 
-func init() { proto.RegisterFile("chat-gateway.proto", fileDescriptor_4b278c71b6605e99) }
+func (m *Message) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
 
-var fileDescriptor_4b278c71b6605e99 = []byte{
-	// 242 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0xce, 0x3d, 0x4a, 0x04, 0x31,
-	0x14, 0xc0, 0x71, 0x12, 0x16, 0x65, 0xb3, 0x60, 0xf1, 0x04, 0x19, 0xc6, 0x0f, 0x24, 0x36, 0xb2,
-	0xb0, 0x89, 0x1f, 0xdd, 0xf6, 0x56, 0x62, 0xa3, 0x85, 0x75, 0x32, 0xf3, 0x1c, 0x03, 0x3a, 0x09,
-	0xc9, 0xdb, 0x51, 0x5b, 0xaf, 0xe0, 0x51, 0x3c, 0x8a, 0x57, 0xf0, 0x20, 0x92, 0xec, 0x2c, 0x88,
-	0x60, 0x97, 0xcf, 0xdf, 0xff, 0x09, 0x68, 0x1e, 0x0d, 0x2d, 0x3a, 0x43, 0xf8, 0x62, 0xde, 0x54,
-	0x88, 0x9e, 0x3c, 0xf0, 0x60, 0xeb, 0x83, 0xce, 0xfb, 0xee, 0x09, 0xb5, 0x09, 0x4e, 0x9b, 0xbe,
-	0xf7, 0x64, 0xc8, 0xf9, 0x3e, 0xad, 0x5f, 0xd4, 0xfb, 0xe3, 0x6d, 0xd9, 0xd9, 0xd5, 0x83, 0xc6,
-	0xe7, 0x40, 0xe3, 0x77, 0xb9, 0x10, 0xdb, 0x37, 0x98, 0x92, 0xe9, 0x10, 0x76, 0x04, 0x77, 0x6d,
-	0xc5, 0x8e, 0xd9, 0xe9, 0xf4, 0x96, 0xbb, 0x16, 0x40, 0x4c, 0x08, 0x5f, 0xa9, 0xe2, 0xe5, 0xa4,
-	0xac, 0x2f, 0x3e, 0x99, 0x98, 0xe5, 0x21, 0xee, 0x30, 0x0e, 0xae, 0x41, 0xb8, 0x16, 0x93, 0x84,
-	0x7d, 0x0b, 0x33, 0x15, 0xac, 0x1a, 0xa1, 0x7a, 0x4f, 0xad, 0x8b, 0x6a, 0x53, 0x54, 0x57, 0xb9,
-	0x28, 0x8f, 0xde, 0xbf, 0xbe, 0x3f, 0x78, 0x25, 0x77, 0xf5, 0x70, 0xae, 0xb3, 0x92, 0x30, 0x0e,
-	0x18, 0x75, 0x16, 0x96, 0x6c, 0x0e, 0xf7, 0x62, 0x9a, 0x56, 0x36, 0x35, 0xd1, 0x59, 0x84, 0x7f,
-	0x90, 0xfa, 0x77, 0x49, 0x9e, 0x14, 0xf1, 0x50, 0x56, 0x7f, 0xc5, 0x0d, 0xb3, 0x64, 0xf3, 0x33,
-	0x66, 0xb7, 0x8a, 0x71, 0xf9, 0x13, 0x00, 0x00, 0xff, 0xff, 0xd9, 0x4f, 0x79, 0xac, 0x40, 0x01,
-	0x00, 0x00,
+func (m *Message) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *Message) GetRecipients() []string {
+	if m != nil {
+		return m.Recipients
+	}
+	return nil
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+type isMessage_Event interface {
+	isMessage_Event()
+}
 
-// ChatServiceClient is the client API for ChatService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ChatServiceClient interface {
-	Send(ctx context.Context, in *Message, opts ...grpc.CallOption) (*empty.Empty, error)
-	Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (ChatService_SubscribeClient, error)
+type Message_Presence struct {
+	Presence *Presence `protobuf:"bytes,7,opt,name=presence,proto3,oneof"`
 }
 
-type chatServiceClient struct {
-	cc *grpc.ClientConn
+type Message_NicknameChange struct {
+	NicknameChange *NicknameChange `protobuf:"bytes,10,opt,name=nickname_change,json=nicknameChange,proto3,oneof"`
 }
 
-func NewChatServiceClient(cc *grpc.ClientConn) ChatServiceClient {
-	return &chatServiceClient{cc}
+type Message_Edit struct {
+	Edit *MessageEdit `protobuf:"bytes,14,opt,name=edit,proto3,oneof"`
 }
 
-func (c *chatServiceClient) Send(ctx context.Context, in *Message, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.chatService/send", in, out, opts...)
-	if err != nil {
-		return nil, err
+type Message_Delete struct {
+	Delete *MessageDelete `protobuf:"bytes,15,opt,name=delete,proto3,oneof"`
+}
+
+type Message_Typing struct {
+	Typing *Typing `protobuf:"bytes,16,opt,name=typing,proto3,oneof"`
+}
+
+type Message_Moderation struct {
+	Moderation *Moderation `protobuf:"bytes,22,opt,name=moderation,proto3,oneof"`
+}
+
+func (*Message_Presence) isMessage_Event() {}
+
+func (*Message_NicknameChange) isMessage_Event() {}
+
+func (*Message_Edit) isMessage_Event() {}
+
+func (*Message_Delete) isMessage_Event() {}
+
+func (*Message_Typing) isMessage_Event() {}
+
+func (*Message_Moderation) isMessage_Event() {}
+
+func (m *Message) GetEvent() isMessage_Event {
+	if m != nil {
+		return m.Event
 	}
-	return out, nil
+	return nil
 }
 
-func (c *chatServiceClient) Subscribe(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (ChatService_SubscribeClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_ChatService_serviceDesc.Streams[0], "/pb.chatService/subscribe", opts...)
-	if err != nil {
-		return nil, err
+func (m *Message) GetPresence() *Presence {
+	if x, ok := m.GetEvent().(*Message_Presence); ok {
+		return x.Presence
 	}
-	x := &chatServiceSubscribeClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	return nil
+}
+
+func (m *Message) GetNicknameChange() *NicknameChange {
+	if x, ok := m.GetEvent().(*Message_NicknameChange); ok {
+		return x.NicknameChange
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	return nil
+}
+
+func (m *Message) GetEdit() *MessageEdit {
+	if x, ok := m.GetEvent().(*Message_Edit); ok {
+		return x.Edit
 	}
-	return x, nil
+	return nil
 }
 
-type ChatService_SubscribeClient interface {
-	Recv() (*Message, error)
-	grpc.ClientStream
+func (m *Message) GetDelete() *MessageDelete {
+	if x, ok := m.GetEvent().(*Message_Delete); ok {
+		return x.Delete
+	}
+	return nil
 }
 
-type chatServiceSubscribeClient struct {
-	grpc.ClientStream
+func (m *Message) GetTyping() *Typing {
+	if x, ok := m.GetEvent().(*Message_Typing); ok {
+		return x.Typing
+	}
+	return nil
 }
 
-func (x *chatServiceSubscribeClient) Recv() (*Message, error) {
-	m := new(Message)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *Message) GetModeration() *Moderation {
+	if x, ok := m.GetEvent().(*Message_Moderation); ok {
+		return x.Moderation
 	}
-	return m, nil
+	return nil
 }
 
-// ChatServiceServer is the server API for ChatService service.
-type ChatServiceServer interface {
-	Send(context.Context, *Message) (*empty.Empty, error)
-	Subscribe(*empty.Empty, ChatService_SubscribeServer) error
+func (m *Message) GetDisplayName() string {
+	if m != nil {
+		return m.DisplayName
+	}
+	return ""
 }
 
-func RegisterChatServiceServer(s *grpc.Server, srv ChatServiceServer) {
-	s.RegisterService(&_ChatService_serviceDesc, srv)
+func (m *Message) GetAvatarUrl() string {
+	if m != nil {
+		return m.AvatarUrl
+	}
+	return ""
 }
 
-func _ChatService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Message)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *Message) GetMessageId() string {
+	if m != nil {
+		return m.MessageId
 	}
-	if interceptor == nil {
-		return srv.(ChatServiceServer).Send(ctx, in)
+	return ""
+}
+
+func (m *Message) GetEditedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.EditedAt
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/pb.chatService/Send",
+	return nil
+}
+
+func (m *Message) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServiceServer).Send(ctx, req.(*Message))
+	return false
+}
+
+type isMessage_Content interface {
+	isMessage_Content()
+}
+
+type Message_Markdown struct {
+	Markdown *Markdown `protobuf:"bytes,18,opt,name=markdown,proto3,oneof"`
+}
+
+type Message_Code struct {
+	Code *Code `protobuf:"bytes,19,opt,name=code,proto3,oneof"`
+}
+
+type Message_Attachment struct {
+	Attachment *Attachment `protobuf:"bytes,20,opt,name=attachment,proto3,oneof"`
+}
+
+type Message_Notice struct {
+	Notice *Notice `protobuf:"bytes,21,opt,name=notice,proto3,oneof"`
+}
+
+func (*Message_Markdown) isMessage_Content() {}
+
+func (*Message_Code) isMessage_Content() {}
+
+func (*Message_Attachment) isMessage_Content() {}
+
+func (*Message_Notice) isMessage_Content() {}
+
+func (m *Message) GetContent() isMessage_Content {
+	if m != nil {
+		return m.Content
 	}
-	return interceptor(ctx, in, info, handler)
+	return nil
 }
 
-func _ChatService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(empty.Empty)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (m *Message) GetMarkdown() *Markdown {
+	if x, ok := m.GetContent().(*Message_Markdown); ok {
+		return x.Markdown
 	}
-	return srv.(ChatServiceServer).Subscribe(m, &chatServiceSubscribeServer{stream})
+	return nil
 }
 
-type ChatService_SubscribeServer interface {
-	Send(*Message) error
-	grpc.ServerStream
+func (m *Message) GetCode() *Code {
+	if x, ok := m.GetContent().(*Message_Code); ok {
+		return x.Code
+	}
+	return nil
 }
 
-type chatServiceSubscribeServer struct {
-	grpc.ServerStream
+func (m *Message) GetAttachment() *Attachment {
+	if x, ok := m.GetContent().(*Message_Attachment); ok {
+		return x.Attachment
+	}
+	return nil
 }
 
-func (x *chatServiceSubscribeServer) Send(m *Message) error {
-	return x.ServerStream.SendMsg(m)
+func (m *Message) GetNotice() *Notice {
+	if x, ok := m.GetContent().(*Message_Notice); ok {
+		return x.Notice
+	}
+	return nil
 }
 
-var _ChatService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "pb.chatService",
-	HandlerType: (*ChatServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "send",
-			Handler:    _ChatService_Send_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "subscribe",
-			Handler:       _ChatService_Subscribe_Handler,
-			ServerStreams: true,
+func (m *Message) GetSchema() SchemaVersion {
+	if m != nil {
+		return m.Schema
+	}
+	return SchemaVersion_SCHEMA_UNKNOWN
+}
+
+func (m *Message) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Message) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Message_Presence)(nil),
+		(*Message_NicknameChange)(nil),
+		(*Message_Edit)(nil),
+		(*Message_Delete)(nil),
+		(*Message_Typing)(nil),
+		(*Message_Moderation)(nil),
+		(*Message_Markdown)(nil),
+		(*Message_Code)(nil),
+		(*Message_Attachment)(nil),
+		(*Message_Notice)(nil),
+	}
+}
+
+type Markdown struct {
+	Source               string   `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Markdown) Reset()         { *m = Markdown{} }
+func (m *Markdown) String() string { return proto.CompactTextString(m) }
+func (*Markdown) ProtoMessage()    {}
+func (*Markdown) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{1}
+}
+
+func (m *Markdown) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Markdown.Unmarshal(m, b)
+}
+func (m *Markdown) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Markdown.Marshal(b, m, deterministic)
+}
+func (m *Markdown) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Markdown.Merge(m, src)
+}
+func (m *Markdown) XXX_Size() int {
+	return xxx_messageInfo_Markdown.Size(m)
+}
+func (m *Markdown) XXX_DiscardUnknown() {
+	xxx_messageInfo_Markdown.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Markdown proto.InternalMessageInfo
+
+func (m *Markdown) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+type Code struct {
+	// language is a hint for highlighting, such as "go"; empty when unknown.
+	Language             string   `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	Source               string   `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Code) Reset()         { *m = Code{} }
+func (m *Code) String() string { return proto.CompactTextString(m) }
+func (*Code) ProtoMessage()    {}
+func (*Code) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{2}
+}
+
+func (m *Code) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Code.Unmarshal(m, b)
+}
+func (m *Code) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Code.Marshal(b, m, deterministic)
+}
+func (m *Code) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Code.Merge(m, src)
+}
+func (m *Code) XXX_Size() int {
+	return xxx_messageInfo_Code.Size(m)
+}
+func (m *Code) XXX_DiscardUnknown() {
+	xxx_messageInfo_Code.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Code proto.InternalMessageInfo
+
+func (m *Code) GetLanguage() string {
+	if m != nil {
+		return m.Language
+	}
+	return ""
+}
+
+func (m *Code) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+// Attachment refers to a file stored apart from the message, either in the
+// server's blob store under id or anywhere else under url.
+type Attachment struct {
+	// id is the hex SHA-256 of the content, returned by uploadAttachment.
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType          string   `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size                 int64    `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Url                  string   `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Attachment) Reset()         { *m = Attachment{} }
+func (m *Attachment) String() string { return proto.CompactTextString(m) }
+func (*Attachment) ProtoMessage()    {}
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{3}
+}
+
+func (m *Attachment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Attachment.Unmarshal(m, b)
+}
+func (m *Attachment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Attachment.Marshal(b, m, deterministic)
+}
+func (m *Attachment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Attachment.Merge(m, src)
+}
+func (m *Attachment) XXX_Size() int {
+	return xxx_messageInfo_Attachment.Size(m)
+}
+func (m *Attachment) XXX_DiscardUnknown() {
+	xxx_messageInfo_Attachment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Attachment proto.InternalMessageInfo
+
+func (m *Attachment) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Attachment) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Attachment) GetContentType() string {
+	if m != nil {
+		return m.ContentType
+	}
+	return ""
+}
+
+func (m *Attachment) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Attachment) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+// AttachmentChunk is a piece of a file in transfer. The first chunk of an
+// upload or download carries the info, name and content_type on upload and
+// the stored id, size and content_type on download; the others carry data.
+type AttachmentChunk struct {
+	// Types that are valid to be assigned to Part:
+	//	*AttachmentChunk_Info
+	//	*AttachmentChunk_Data
+	Part                 isAttachmentChunk_Part `protobuf_oneof:"part"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *AttachmentChunk) Reset()         { *m = AttachmentChunk{} }
+func (m *AttachmentChunk) String() string { return proto.CompactTextString(m) }
+func (*AttachmentChunk) ProtoMessage()    {}
+func (*AttachmentChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{4}
+}
+
+func (m *AttachmentChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AttachmentChunk.Unmarshal(m, b)
+}
+func (m *AttachmentChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AttachmentChunk.Marshal(b, m, deterministic)
+}
+func (m *AttachmentChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AttachmentChunk.Merge(m, src)
+}
+func (m *AttachmentChunk) XXX_Size() int {
+	return xxx_messageInfo_AttachmentChunk.Size(m)
+}
+func (m *AttachmentChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_AttachmentChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AttachmentChunk proto.InternalMessageInfo
+
+type isAttachmentChunk_Part interface {
+	isAttachmentChunk_Part()
+}
+
+type AttachmentChunk_Info struct {
+	Info *Attachment `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+}
+
+type AttachmentChunk_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+func (*AttachmentChunk_Info) isAttachmentChunk_Part() {}
+
+func (*AttachmentChunk_Data) isAttachmentChunk_Part() {}
+
+func (m *AttachmentChunk) GetPart() isAttachmentChunk_Part {
+	if m != nil {
+		return m.Part
+	}
+	return nil
+}
+
+func (m *AttachmentChunk) GetInfo() *Attachment {
+	if x, ok := m.GetPart().(*AttachmentChunk_Info); ok {
+		return x.Info
+	}
+	return nil
+}
+
+func (m *AttachmentChunk) GetData() []byte {
+	if x, ok := m.GetPart().(*AttachmentChunk_Data); ok {
+		return x.Data
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*AttachmentChunk) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*AttachmentChunk_Info)(nil),
+		(*AttachmentChunk_Data)(nil),
+	}
+}
+
+type AttachmentRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AttachmentRequest) Reset()         { *m = AttachmentRequest{} }
+func (m *AttachmentRequest) String() string { return proto.CompactTextString(m) }
+func (*AttachmentRequest) ProtoMessage()    {}
+func (*AttachmentRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{5}
+}
+
+func (m *AttachmentRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AttachmentRequest.Unmarshal(m, b)
+}
+func (m *AttachmentRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AttachmentRequest.Marshal(b, m, deterministic)
+}
+func (m *AttachmentRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AttachmentRequest.Merge(m, src)
+}
+func (m *AttachmentRequest) XXX_Size() int {
+	return xxx_messageInfo_AttachmentRequest.Size(m)
+}
+func (m *AttachmentRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AttachmentRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AttachmentRequest proto.InternalMessageInfo
+
+func (m *AttachmentRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type Notice struct {
+	Level                Notice_Level `protobuf:"varint,1,opt,name=level,proto3,enum=pb.Notice_Level" json:"level,omitempty"`
+	Text                 string       `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *Notice) Reset()         { *m = Notice{} }
+func (m *Notice) String() string { return proto.CompactTextString(m) }
+func (*Notice) ProtoMessage()    {}
+func (*Notice) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{6}
+}
+
+func (m *Notice) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Notice.Unmarshal(m, b)
+}
+func (m *Notice) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Notice.Marshal(b, m, deterministic)
+}
+func (m *Notice) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Notice.Merge(m, src)
+}
+func (m *Notice) XXX_Size() int {
+	return xxx_messageInfo_Notice.Size(m)
+}
+func (m *Notice) XXX_DiscardUnknown() {
+	xxx_messageInfo_Notice.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Notice proto.InternalMessageInfo
+
+func (m *Notice) GetLevel() Notice_Level {
+	if m != nil {
+		return m.Level
+	}
+	return Notice_INFO
+}
+
+func (m *Notice) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+type Presence struct {
+	Id         string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	User       string               `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	State      Presence_State       `protobuf:"varint,3,opt,name=state,proto3,enum=pb.Presence_State" json:"state,omitempty"`
+	LastActive *timestamp.Timestamp `protobuf:"bytes,4,opt,name=last_active,json=lastActive,proto3" json:"last_active,omitempty"`
+	// rooms is only filled in by listOnline.
+	Rooms                []string `protobuf:"bytes,5,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Presence) Reset()         { *m = Presence{} }
+func (m *Presence) String() string { return proto.CompactTextString(m) }
+func (*Presence) ProtoMessage()    {}
+func (*Presence) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{7}
+}
+
+func (m *Presence) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Presence.Unmarshal(m, b)
+}
+func (m *Presence) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Presence.Marshal(b, m, deterministic)
+}
+func (m *Presence) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Presence.Merge(m, src)
+}
+func (m *Presence) XXX_Size() int {
+	return xxx_messageInfo_Presence.Size(m)
+}
+func (m *Presence) XXX_DiscardUnknown() {
+	xxx_messageInfo_Presence.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Presence proto.InternalMessageInfo
+
+func (m *Presence) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Presence) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *Presence) GetState() Presence_State {
+	if m != nil {
+		return m.State
+	}
+	return Presence_UNKNOWN
+}
+
+func (m *Presence) GetLastActive() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastActive
+	}
+	return nil
+}
+
+func (m *Presence) GetRooms() []string {
+	if m != nil {
+		return m.Rooms
+	}
+	return nil
+}
+
+type Profile struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// nickname is unique among the sessions online; empty until set.
+	Nickname             string   `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	AvatarUrl            string   `protobuf:"bytes,3,opt,name=avatar_url,json=avatarUrl,proto3" json:"avatar_url,omitempty"`
+	Role                 Role     `protobuf:"varint,4,opt,name=role,proto3,enum=pb.Role" json:"role,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Profile) Reset()         { *m = Profile{} }
+func (m *Profile) String() string { return proto.CompactTextString(m) }
+func (*Profile) ProtoMessage()    {}
+func (*Profile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{8}
+}
+
+func (m *Profile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Profile.Unmarshal(m, b)
+}
+func (m *Profile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Profile.Marshal(b, m, deterministic)
+}
+func (m *Profile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Profile.Merge(m, src)
+}
+func (m *Profile) XXX_Size() int {
+	return xxx_messageInfo_Profile.Size(m)
+}
+func (m *Profile) XXX_DiscardUnknown() {
+	xxx_messageInfo_Profile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Profile proto.InternalMessageInfo
+
+func (m *Profile) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Profile) GetNickname() string {
+	if m != nil {
+		return m.Nickname
+	}
+	return ""
+}
+
+func (m *Profile) GetAvatarUrl() string {
+	if m != nil {
+		return m.AvatarUrl
+	}
+	return ""
+}
+
+func (m *Profile) GetRole() Role {
+	if m != nil {
+		return m.Role
+	}
+	return Role_MEMBER
+}
+
+type SetNicknameRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nickname             string   `protobuf:"bytes,2,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	AvatarUrl            string   `protobuf:"bytes,3,opt,name=avatar_url,json=avatarUrl,proto3" json:"avatar_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetNicknameRequest) Reset()         { *m = SetNicknameRequest{} }
+func (m *SetNicknameRequest) String() string { return proto.CompactTextString(m) }
+func (*SetNicknameRequest) ProtoMessage()    {}
+func (*SetNicknameRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{9}
+}
+
+func (m *SetNicknameRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetNicknameRequest.Unmarshal(m, b)
+}
+func (m *SetNicknameRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetNicknameRequest.Marshal(b, m, deterministic)
+}
+func (m *SetNicknameRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetNicknameRequest.Merge(m, src)
+}
+func (m *SetNicknameRequest) XXX_Size() int {
+	return xxx_messageInfo_SetNicknameRequest.Size(m)
+}
+func (m *SetNicknameRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetNicknameRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetNicknameRequest proto.InternalMessageInfo
+
+func (m *SetNicknameRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *SetNicknameRequest) GetNickname() string {
+	if m != nil {
+		return m.Nickname
+	}
+	return ""
+}
+
+func (m *SetNicknameRequest) GetAvatarUrl() string {
+	if m != nil {
+		return m.AvatarUrl
+	}
+	return ""
+}
+
+type ProfileRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProfileRequest) Reset()         { *m = ProfileRequest{} }
+func (m *ProfileRequest) String() string { return proto.CompactTextString(m) }
+func (*ProfileRequest) ProtoMessage()    {}
+func (*ProfileRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{10}
+}
+
+func (m *ProfileRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProfileRequest.Unmarshal(m, b)
+}
+func (m *ProfileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProfileRequest.Marshal(b, m, deterministic)
+}
+func (m *ProfileRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProfileRequest.Merge(m, src)
+}
+func (m *ProfileRequest) XXX_Size() int {
+	return xxx_messageInfo_ProfileRequest.Size(m)
+}
+func (m *ProfileRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProfileRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProfileRequest proto.InternalMessageInfo
+
+func (m *ProfileRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// NicknameChange is sent to every session when one changes its nickname.
+type NicknameChange struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OldNickname          string   `protobuf:"bytes,2,opt,name=old_nickname,json=oldNickname,proto3" json:"old_nickname,omitempty"`
+	Nickname             string   `protobuf:"bytes,3,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NicknameChange) Reset()         { *m = NicknameChange{} }
+func (m *NicknameChange) String() string { return proto.CompactTextString(m) }
+func (*NicknameChange) ProtoMessage()    {}
+func (*NicknameChange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{11}
+}
+
+func (m *NicknameChange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NicknameChange.Unmarshal(m, b)
+}
+func (m *NicknameChange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NicknameChange.Marshal(b, m, deterministic)
+}
+func (m *NicknameChange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NicknameChange.Merge(m, src)
+}
+func (m *NicknameChange) XXX_Size() int {
+	return xxx_messageInfo_NicknameChange.Size(m)
+}
+func (m *NicknameChange) XXX_DiscardUnknown() {
+	xxx_messageInfo_NicknameChange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NicknameChange proto.InternalMessageInfo
+
+func (m *NicknameChange) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *NicknameChange) GetOldNickname() string {
+	if m != nil {
+		return m.OldNickname
+	}
+	return ""
+}
+
+func (m *NicknameChange) GetNickname() string {
+	if m != nil {
+		return m.Nickname
+	}
+	return ""
+}
+
+// MessageEdit is sent to the audience of a message when its text changed.
+type MessageEdit struct {
+	MessageId            string               `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Text                 string               `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	EditedAt             *timestamp.Timestamp `protobuf:"bytes,3,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *MessageEdit) Reset()         { *m = MessageEdit{} }
+func (m *MessageEdit) String() string { return proto.CompactTextString(m) }
+func (*MessageEdit) ProtoMessage()    {}
+func (*MessageEdit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{12}
+}
+
+func (m *MessageEdit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessageEdit.Unmarshal(m, b)
+}
+func (m *MessageEdit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessageEdit.Marshal(b, m, deterministic)
+}
+func (m *MessageEdit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessageEdit.Merge(m, src)
+}
+func (m *MessageEdit) XXX_Size() int {
+	return xxx_messageInfo_MessageEdit.Size(m)
+}
+func (m *MessageEdit) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessageEdit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessageEdit proto.InternalMessageInfo
+
+func (m *MessageEdit) GetMessageId() string {
+	if m != nil {
+		return m.MessageId
+	}
+	return ""
+}
+
+func (m *MessageEdit) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+func (m *MessageEdit) GetEditedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.EditedAt
+	}
+	return nil
+}
+
+// MessageDelete is sent to the audience of a message when it was deleted.
+type MessageDelete struct {
+	MessageId            string   `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MessageDelete) Reset()         { *m = MessageDelete{} }
+func (m *MessageDelete) String() string { return proto.CompactTextString(m) }
+func (*MessageDelete) ProtoMessage()    {}
+func (*MessageDelete) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{13}
+}
+
+func (m *MessageDelete) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessageDelete.Unmarshal(m, b)
+}
+func (m *MessageDelete) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessageDelete.Marshal(b, m, deterministic)
+}
+func (m *MessageDelete) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessageDelete.Merge(m, src)
+}
+func (m *MessageDelete) XXX_Size() int {
+	return xxx_messageInfo_MessageDelete.Size(m)
+}
+func (m *MessageDelete) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessageDelete.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessageDelete proto.InternalMessageInfo
+
+func (m *MessageDelete) GetMessageId() string {
+	if m != nil {
+		return m.MessageId
+	}
+	return ""
+}
+
+// Typing is sent to a room when the session the message is from starts or
+// stops typing. Clients should also drop the indicator of a session that
+// left the room.
+type Typing struct {
+	Typing               bool     `protobuf:"varint,1,opt,name=typing,proto3" json:"typing,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Typing) Reset()         { *m = Typing{} }
+func (m *Typing) String() string { return proto.CompactTextString(m) }
+func (*Typing) ProtoMessage()    {}
+func (*Typing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{14}
+}
+
+func (m *Typing) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Typing.Unmarshal(m, b)
+}
+func (m *Typing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Typing.Marshal(b, m, deterministic)
+}
+func (m *Typing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Typing.Merge(m, src)
+}
+func (m *Typing) XXX_Size() int {
+	return xxx_messageInfo_Typing.Size(m)
+}
+func (m *Typing) XXX_DiscardUnknown() {
+	xxx_messageInfo_Typing.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Typing proto.InternalMessageInfo
+
+func (m *Typing) GetTyping() bool {
+	if m != nil {
+		return m.Typing
+	}
+	return false
+}
+
+// TypingRequest starts or stops the typing indicator of a session in a room.
+// A started indicator stops by itself unless it is started again before the
+// server's typing timeout.
+type TypingRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Room                 string   `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	Typing               bool     `protobuf:"varint,3,opt,name=typing,proto3" json:"typing,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TypingRequest) Reset()         { *m = TypingRequest{} }
+func (m *TypingRequest) String() string { return proto.CompactTextString(m) }
+func (*TypingRequest) ProtoMessage()    {}
+func (*TypingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{15}
+}
+
+func (m *TypingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TypingRequest.Unmarshal(m, b)
+}
+func (m *TypingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TypingRequest.Marshal(b, m, deterministic)
+}
+func (m *TypingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TypingRequest.Merge(m, src)
+}
+func (m *TypingRequest) XXX_Size() int {
+	return xxx_messageInfo_TypingRequest.Size(m)
+}
+func (m *TypingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TypingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TypingRequest proto.InternalMessageInfo
+
+func (m *TypingRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *TypingRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *TypingRequest) GetTyping() bool {
+	if m != nil {
+		return m.Typing
+	}
+	return false
+}
+
+type EditMessageRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MessageId            string   `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Text                 string   `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EditMessageRequest) Reset()         { *m = EditMessageRequest{} }
+func (m *EditMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*EditMessageRequest) ProtoMessage()    {}
+func (*EditMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{16}
+}
+
+func (m *EditMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EditMessageRequest.Unmarshal(m, b)
+}
+func (m *EditMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EditMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *EditMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EditMessageRequest.Merge(m, src)
+}
+func (m *EditMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_EditMessageRequest.Size(m)
+}
+func (m *EditMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EditMessageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EditMessageRequest proto.InternalMessageInfo
+
+func (m *EditMessageRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *EditMessageRequest) GetMessageId() string {
+	if m != nil {
+		return m.MessageId
+	}
+	return ""
+}
+
+func (m *EditMessageRequest) GetText() string {
+	if m != nil {
+		return m.Text
+	}
+	return ""
+}
+
+type DeleteMessageRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MessageId            string   `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteMessageRequest) Reset()         { *m = DeleteMessageRequest{} }
+func (m *DeleteMessageRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteMessageRequest) ProtoMessage()    {}
+func (*DeleteMessageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{17}
+}
+
+func (m *DeleteMessageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteMessageRequest.Unmarshal(m, b)
+}
+func (m *DeleteMessageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteMessageRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteMessageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteMessageRequest.Merge(m, src)
+}
+func (m *DeleteMessageRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteMessageRequest.Size(m)
+}
+func (m *DeleteMessageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteMessageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteMessageRequest proto.InternalMessageInfo
+
+func (m *DeleteMessageRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *DeleteMessageRequest) GetMessageId() string {
+	if m != nil {
+		return m.MessageId
+	}
+	return ""
+}
+
+// ModerationRequest asks to act on the session target, which must have a
+// lower role than the caller. duration limits a ban or mute, a ban without
+// it is permanent and a mute without it is lifted. by_address bans the
+// address of the target as well as its identity, unless the target came
+// through a gateway the server does not trust with its X-Forwarded-For.
+type ModerationRequest struct {
+	Id                   string             `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Target               string             `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Reason               string             `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Duration             *duration.Duration `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	ByAddress            bool               `protobuf:"varint,5,opt,name=by_address,json=byAddress,proto3" json:"by_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ModerationRequest) Reset()         { *m = ModerationRequest{} }
+func (m *ModerationRequest) String() string { return proto.CompactTextString(m) }
+func (*ModerationRequest) ProtoMessage()    {}
+func (*ModerationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{18}
+}
+
+func (m *ModerationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModerationRequest.Unmarshal(m, b)
+}
+func (m *ModerationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModerationRequest.Marshal(b, m, deterministic)
+}
+func (m *ModerationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModerationRequest.Merge(m, src)
+}
+func (m *ModerationRequest) XXX_Size() int {
+	return xxx_messageInfo_ModerationRequest.Size(m)
+}
+func (m *ModerationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModerationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModerationRequest proto.InternalMessageInfo
+
+func (m *ModerationRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ModerationRequest) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *ModerationRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ModerationRequest) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *ModerationRequest) GetByAddress() bool {
+	if m != nil {
+		return m.ByAddress
+	}
+	return false
+}
+
+// UnbanRequest lifts the bans on a user name, certificate identity or
+// address.
+type UnbanRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Identity             string   `protobuf:"bytes,2,opt,name=identity,proto3" json:"identity,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnbanRequest) Reset()         { *m = UnbanRequest{} }
+func (m *UnbanRequest) String() string { return proto.CompactTextString(m) }
+func (*UnbanRequest) ProtoMessage()    {}
+func (*UnbanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{19}
+}
+
+func (m *UnbanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnbanRequest.Unmarshal(m, b)
+}
+func (m *UnbanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnbanRequest.Marshal(b, m, deterministic)
+}
+func (m *UnbanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnbanRequest.Merge(m, src)
+}
+func (m *UnbanRequest) XXX_Size() int {
+	return xxx_messageInfo_UnbanRequest.Size(m)
+}
+func (m *UnbanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnbanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnbanRequest proto.InternalMessageInfo
+
+func (m *UnbanRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *UnbanRequest) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+// Moderation is sent to the rooms of a session a moderator acted on.
+type Moderation struct {
+	Action Moderation_Action `protobuf:"varint,1,opt,name=action,proto3,enum=pb.Moderation_Action" json:"action,omitempty"`
+	Target string            `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Reason string            `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// until is when a ban or mute ends, unset when it does not.
+	Until                *timestamp.Timestamp `protobuf:"bytes,4,opt,name=until,proto3" json:"until,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Moderation) Reset()         { *m = Moderation{} }
+func (m *Moderation) String() string { return proto.CompactTextString(m) }
+func (*Moderation) ProtoMessage()    {}
+func (*Moderation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{20}
+}
+
+func (m *Moderation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Moderation.Unmarshal(m, b)
+}
+func (m *Moderation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Moderation.Marshal(b, m, deterministic)
+}
+func (m *Moderation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Moderation.Merge(m, src)
+}
+func (m *Moderation) XXX_Size() int {
+	return xxx_messageInfo_Moderation.Size(m)
+}
+func (m *Moderation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Moderation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Moderation proto.InternalMessageInfo
+
+func (m *Moderation) GetAction() Moderation_Action {
+	if m != nil {
+		return m.Action
+	}
+	return Moderation_NONE
+}
+
+func (m *Moderation) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *Moderation) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *Moderation) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+// ChatFrame is sent by the client on a chat stream. The ids in the requests
+// may be left empty, they are those of the stream's session.
+type ChatFrame struct {
+	// Types that are valid to be assigned to Frame:
+	//	*ChatFrame_Subscribe
+	//	*ChatFrame_Message
+	//	*ChatFrame_Typing
+	//	*ChatFrame_Edit
+	//	*ChatFrame_Delete
+	//	*ChatFrame_Join
+	//	*ChatFrame_Leave
+	//	*ChatFrame_Nickname
+	Frame                isChatFrame_Frame `protobuf_oneof:"frame"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ChatFrame) Reset()         { *m = ChatFrame{} }
+func (m *ChatFrame) String() string { return proto.CompactTextString(m) }
+func (*ChatFrame) ProtoMessage()    {}
+func (*ChatFrame) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{21}
+}
+
+func (m *ChatFrame) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChatFrame.Unmarshal(m, b)
+}
+func (m *ChatFrame) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChatFrame.Marshal(b, m, deterministic)
+}
+func (m *ChatFrame) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChatFrame.Merge(m, src)
+}
+func (m *ChatFrame) XXX_Size() int {
+	return xxx_messageInfo_ChatFrame.Size(m)
+}
+func (m *ChatFrame) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChatFrame.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChatFrame proto.InternalMessageInfo
+
+type isChatFrame_Frame interface {
+	isChatFrame_Frame()
+}
+
+type ChatFrame_Subscribe struct {
+	Subscribe *SubscribeRequest `protobuf:"bytes,1,opt,name=subscribe,proto3,oneof"`
+}
+
+type ChatFrame_Message struct {
+	Message *Message `protobuf:"bytes,2,opt,name=message,proto3,oneof"`
+}
+
+type ChatFrame_Typing struct {
+	Typing *TypingRequest `protobuf:"bytes,3,opt,name=typing,proto3,oneof"`
+}
+
+type ChatFrame_Edit struct {
+	Edit *EditMessageRequest `protobuf:"bytes,4,opt,name=edit,proto3,oneof"`
+}
+
+type ChatFrame_Delete struct {
+	Delete *DeleteMessageRequest `protobuf:"bytes,5,opt,name=delete,proto3,oneof"`
+}
+
+type ChatFrame_Join struct {
+	Join *RoomRequest `protobuf:"bytes,6,opt,name=join,proto3,oneof"`
+}
+
+type ChatFrame_Leave struct {
+	Leave *RoomRequest `protobuf:"bytes,7,opt,name=leave,proto3,oneof"`
+}
+
+type ChatFrame_Nickname struct {
+	Nickname *SetNicknameRequest `protobuf:"bytes,8,opt,name=nickname,proto3,oneof"`
+}
+
+func (*ChatFrame_Subscribe) isChatFrame_Frame() {}
+
+func (*ChatFrame_Message) isChatFrame_Frame() {}
+
+func (*ChatFrame_Typing) isChatFrame_Frame() {}
+
+func (*ChatFrame_Edit) isChatFrame_Frame() {}
+
+func (*ChatFrame_Delete) isChatFrame_Frame() {}
+
+func (*ChatFrame_Join) isChatFrame_Frame() {}
+
+func (*ChatFrame_Leave) isChatFrame_Frame() {}
+
+func (*ChatFrame_Nickname) isChatFrame_Frame() {}
+
+func (m *ChatFrame) GetFrame() isChatFrame_Frame {
+	if m != nil {
+		return m.Frame
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetSubscribe() *SubscribeRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Subscribe); ok {
+		return x.Subscribe
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetMessage() *Message {
+	if x, ok := m.GetFrame().(*ChatFrame_Message); ok {
+		return x.Message
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetTyping() *TypingRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Typing); ok {
+		return x.Typing
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetEdit() *EditMessageRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Edit); ok {
+		return x.Edit
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetDelete() *DeleteMessageRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Delete); ok {
+		return x.Delete
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetJoin() *RoomRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Join); ok {
+		return x.Join
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetLeave() *RoomRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Leave); ok {
+		return x.Leave
+	}
+	return nil
+}
+
+func (m *ChatFrame) GetNickname() *SetNicknameRequest {
+	if x, ok := m.GetFrame().(*ChatFrame_Nickname); ok {
+		return x.Nickname
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*ChatFrame) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*ChatFrame_Subscribe)(nil),
+		(*ChatFrame_Message)(nil),
+		(*ChatFrame_Typing)(nil),
+		(*ChatFrame_Edit)(nil),
+		(*ChatFrame_Delete)(nil),
+		(*ChatFrame_Join)(nil),
+		(*ChatFrame_Leave)(nil),
+		(*ChatFrame_Nickname)(nil),
+	}
+}
+
+type SubscribeRequest struct {
+	Room string `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	// resume_from is the seq of the last message received on a previous
+	// stream; messages after it are replayed before live delivery starts.
+	ResumeFrom           uint64   `protobuf:"varint,2,opt,name=resume_from,json=resumeFrom,proto3" json:"resume_from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest) ProtoMessage()    {}
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{22}
+}
+
+func (m *SubscribeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeRequest.Unmarshal(m, b)
+}
+func (m *SubscribeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest.Merge(m, src)
+}
+func (m *SubscribeRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeRequest.Size(m)
+}
+func (m *SubscribeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeRequest proto.InternalMessageInfo
+
+func (m *SubscribeRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *SubscribeRequest) GetResumeFrom() uint64 {
+	if m != nil {
+		return m.ResumeFrom
+	}
+	return 0
+}
+
+type Room struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Members              int32    `protobuf:"varint,2,opt,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Room) Reset()         { *m = Room{} }
+func (m *Room) String() string { return proto.CompactTextString(m) }
+func (*Room) ProtoMessage()    {}
+func (*Room) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{23}
+}
+
+func (m *Room) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Room.Unmarshal(m, b)
+}
+func (m *Room) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Room.Marshal(b, m, deterministic)
+}
+func (m *Room) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Room.Merge(m, src)
+}
+func (m *Room) XXX_Size() int {
+	return xxx_messageInfo_Room.Size(m)
+}
+func (m *Room) XXX_DiscardUnknown() {
+	xxx_messageInfo_Room.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Room proto.InternalMessageInfo
+
+func (m *Room) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Room) GetMembers() int32 {
+	if m != nil {
+		return m.Members
+	}
+	return 0
+}
+
+type RoomList struct {
+	Rooms                []*Room  `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoomList) Reset()         { *m = RoomList{} }
+func (m *RoomList) String() string { return proto.CompactTextString(m) }
+func (*RoomList) ProtoMessage()    {}
+func (*RoomList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{24}
+}
+
+func (m *RoomList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoomList.Unmarshal(m, b)
+}
+func (m *RoomList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoomList.Marshal(b, m, deterministic)
+}
+func (m *RoomList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoomList.Merge(m, src)
+}
+func (m *RoomList) XXX_Size() int {
+	return xxx_messageInfo_RoomList.Size(m)
+}
+func (m *RoomList) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoomList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoomList proto.InternalMessageInfo
+
+func (m *RoomList) GetRooms() []*Room {
+	if m != nil {
+		return m.Rooms
+	}
+	return nil
+}
+
+type RoomRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Room                 string   `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoomRequest) Reset()         { *m = RoomRequest{} }
+func (m *RoomRequest) String() string { return proto.CompactTextString(m) }
+func (*RoomRequest) ProtoMessage()    {}
+func (*RoomRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{25}
+}
+
+func (m *RoomRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoomRequest.Unmarshal(m, b)
+}
+func (m *RoomRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoomRequest.Marshal(b, m, deterministic)
+}
+func (m *RoomRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoomRequest.Merge(m, src)
+}
+func (m *RoomRequest) XXX_Size() int {
+	return xxx_messageInfo_RoomRequest.Size(m)
+}
+func (m *RoomRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoomRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoomRequest proto.InternalMessageInfo
+
+func (m *RoomRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RoomRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+type HistoryRequest struct {
+	Room string `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	// cursor is the next_cursor of a previous response; 0 starts from the newest message.
+	Cursor               uint64   `protobuf:"varint,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit                int32    `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HistoryRequest) Reset()         { *m = HistoryRequest{} }
+func (m *HistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*HistoryRequest) ProtoMessage()    {}
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{26}
+}
+
+func (m *HistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistoryRequest.Unmarshal(m, b)
+}
+func (m *HistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistoryRequest.Marshal(b, m, deterministic)
+}
+func (m *HistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistoryRequest.Merge(m, src)
+}
+func (m *HistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_HistoryRequest.Size(m)
+}
+func (m *HistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistoryRequest proto.InternalMessageInfo
+
+func (m *HistoryRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *HistoryRequest) GetCursor() uint64 {
+	if m != nil {
+		return m.Cursor
+	}
+	return 0
+}
+
+func (m *HistoryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type HistoryResponse struct {
+	Messages []*Message `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	// next_cursor fetches the page of older messages; 0 when there are none left.
+	NextCursor           uint64   `protobuf:"varint,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HistoryResponse) Reset()         { *m = HistoryResponse{} }
+func (m *HistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*HistoryResponse) ProtoMessage()    {}
+func (*HistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{27}
+}
+
+func (m *HistoryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistoryResponse.Unmarshal(m, b)
+}
+func (m *HistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistoryResponse.Marshal(b, m, deterministic)
+}
+func (m *HistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistoryResponse.Merge(m, src)
+}
+func (m *HistoryResponse) XXX_Size() int {
+	return xxx_messageInfo_HistoryResponse.Size(m)
+}
+func (m *HistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistoryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistoryResponse proto.InternalMessageInfo
+
+func (m *HistoryResponse) GetMessages() []*Message {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+func (m *HistoryResponse) GetNextCursor() uint64 {
+	if m != nil {
+		return m.NextCursor
+	}
+	return 0
+}
+
+type LoginRequest struct {
+	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoginRequest) Reset()         { *m = LoginRequest{} }
+func (m *LoginRequest) String() string { return proto.CompactTextString(m) }
+func (*LoginRequest) ProtoMessage()    {}
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{28}
+}
+
+func (m *LoginRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoginRequest.Unmarshal(m, b)
+}
+func (m *LoginRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoginRequest.Marshal(b, m, deterministic)
+}
+func (m *LoginRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoginRequest.Merge(m, src)
+}
+func (m *LoginRequest) XXX_Size() int {
+	return xxx_messageInfo_LoginRequest.Size(m)
+}
+func (m *LoginRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoginRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoginRequest proto.InternalMessageInfo
+
+func (m *LoginRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *LoginRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	// token is sent back as "authorization: Bearer <token>" metadata.
+	Token                string               `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt            *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *LoginResponse) Reset()         { *m = LoginResponse{} }
+func (m *LoginResponse) String() string { return proto.CompactTextString(m) }
+func (*LoginResponse) ProtoMessage()    {}
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{29}
+}
+
+func (m *LoginResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoginResponse.Unmarshal(m, b)
+}
+func (m *LoginResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoginResponse.Marshal(b, m, deterministic)
+}
+func (m *LoginResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoginResponse.Merge(m, src)
+}
+func (m *LoginResponse) XXX_Size() int {
+	return xxx_messageInfo_LoginResponse.Size(m)
+}
+func (m *LoginResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoginResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoginResponse proto.InternalMessageInfo
+
+func (m *LoginResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *LoginResponse) GetExpiresAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return nil
+}
+
+type ListOnlineRequest struct {
+	// room restricts the list to its members; empty lists every session.
+	Room                 string   `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListOnlineRequest) Reset()         { *m = ListOnlineRequest{} }
+func (m *ListOnlineRequest) String() string { return proto.CompactTextString(m) }
+func (*ListOnlineRequest) ProtoMessage()    {}
+func (*ListOnlineRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{30}
+}
+
+func (m *ListOnlineRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListOnlineRequest.Unmarshal(m, b)
+}
+func (m *ListOnlineRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListOnlineRequest.Marshal(b, m, deterministic)
+}
+func (m *ListOnlineRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListOnlineRequest.Merge(m, src)
+}
+func (m *ListOnlineRequest) XXX_Size() int {
+	return xxx_messageInfo_ListOnlineRequest.Size(m)
+}
+func (m *ListOnlineRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListOnlineRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListOnlineRequest proto.InternalMessageInfo
+
+func (m *ListOnlineRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+type OnlineList struct {
+	Online               []*Presence `protobuf:"bytes,1,rep,name=online,proto3" json:"online,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *OnlineList) Reset()         { *m = OnlineList{} }
+func (m *OnlineList) String() string { return proto.CompactTextString(m) }
+func (*OnlineList) ProtoMessage()    {}
+func (*OnlineList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4b278c71b6605e99, []int{31}
+}
+
+func (m *OnlineList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineList.Unmarshal(m, b)
+}
+func (m *OnlineList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineList.Marshal(b, m, deterministic)
+}
+func (m *OnlineList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineList.Merge(m, src)
+}
+func (m *OnlineList) XXX_Size() int {
+	return xxx_messageInfo_OnlineList.Size(m)
+}
+func (m *OnlineList) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineList proto.InternalMessageInfo
+
+func (m *OnlineList) GetOnline() []*Presence {
+	if m != nil {
+		return m.Online
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("pb.SchemaVersion", SchemaVersion_name, SchemaVersion_value)
+	proto.RegisterEnum("pb.Role", Role_name, Role_value)
+	proto.RegisterEnum("pb.Notice_Level", Notice_Level_name, Notice_Level_value)
+	proto.RegisterEnum("pb.Presence_State", Presence_State_name, Presence_State_value)
+	proto.RegisterEnum("pb.Moderation_Action", Moderation_Action_name, Moderation_Action_value)
+	proto.RegisterType((*Message)(nil), "pb.Message")
+	proto.RegisterType((*Markdown)(nil), "pb.Markdown")
+	proto.RegisterType((*Code)(nil), "pb.Code")
+	proto.RegisterType((*Attachment)(nil), "pb.Attachment")
+	proto.RegisterType((*AttachmentChunk)(nil), "pb.AttachmentChunk")
+	proto.RegisterType((*AttachmentRequest)(nil), "pb.AttachmentRequest")
+	proto.RegisterType((*Notice)(nil), "pb.Notice")
+	proto.RegisterType((*Presence)(nil), "pb.Presence")
+	proto.RegisterType((*Profile)(nil), "pb.Profile")
+	proto.RegisterType((*SetNicknameRequest)(nil), "pb.SetNicknameRequest")
+	proto.RegisterType((*ProfileRequest)(nil), "pb.ProfileRequest")
+	proto.RegisterType((*NicknameChange)(nil), "pb.NicknameChange")
+	proto.RegisterType((*MessageEdit)(nil), "pb.MessageEdit")
+	proto.RegisterType((*MessageDelete)(nil), "pb.MessageDelete")
+	proto.RegisterType((*Typing)(nil), "pb.Typing")
+	proto.RegisterType((*TypingRequest)(nil), "pb.TypingRequest")
+	proto.RegisterType((*EditMessageRequest)(nil), "pb.EditMessageRequest")
+	proto.RegisterType((*DeleteMessageRequest)(nil), "pb.DeleteMessageRequest")
+	proto.RegisterType((*ModerationRequest)(nil), "pb.ModerationRequest")
+	proto.RegisterType((*UnbanRequest)(nil), "pb.UnbanRequest")
+	proto.RegisterType((*Moderation)(nil), "pb.Moderation")
+	proto.RegisterType((*ChatFrame)(nil), "pb.ChatFrame")
+	proto.RegisterType((*SubscribeRequest)(nil), "pb.SubscribeRequest")
+	proto.RegisterType((*Room)(nil), "pb.Room")
+	proto.RegisterType((*RoomList)(nil), "pb.RoomList")
+	proto.RegisterType((*RoomRequest)(nil), "pb.RoomRequest")
+	proto.RegisterType((*HistoryRequest)(nil), "pb.HistoryRequest")
+	proto.RegisterType((*HistoryResponse)(nil), "pb.HistoryResponse")
+	proto.RegisterType((*LoginRequest)(nil), "pb.LoginRequest")
+	proto.RegisterType((*LoginResponse)(nil), "pb.LoginResponse")
+	proto.RegisterType((*ListOnlineRequest)(nil), "pb.ListOnlineRequest")
+	proto.RegisterType((*OnlineList)(nil), "pb.OnlineList")
+}
+
+func init() { proto.RegisterFile("chat-gateway.proto", fileDescriptor_4b278c71b6605e99) }
+
+var fileDescriptor_4b278c71b6605e99 = []byte{
+	// 2240 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xcd, 0x76, 0x13, 0xc9,
+	0xf5, 0x57, 0xeb, 0x5b, 0x57, 0xb6, 0x90, 0x0b, 0xe3, 0xe9, 0xd1, 0x80, 0x31, 0x85, 0x67, 0x10,
+	0x1e, 0xb0, 0xc0, 0x7f, 0xfe, 0x27, 0x09, 0x9c, 0x9c, 0x13, 0x61, 0x8b, 0x91, 0x03, 0x96, 0xe7,
+	0xb4, 0x6d, 0x58, 0xe4, 0x24, 0xa2, 0xa5, 0x2e, 0xcb, 0x1d, 0xb7, 0xba, 0x35, 0xdd, 0x25, 0x83,
+	0xc2, 0x61, 0x93, 0x55, 0xf6, 0x79, 0x89, 0xe4, 0x79, 0xb2, 0xcb, 0x3a, 0xdb, 0xac, 0xf2, 0x02,
+	0x39, 0xb7, 0xaa, 0xba, 0xd5, 0x2d, 0x4b, 0x06, 0x32, 0x2b, 0x55, 0xdd, 0x7b, 0xeb, 0x57, 0xf7,
+	0xa3, 0xee, 0x87, 0x1a, 0x48, 0xff, 0xcc, 0xe4, 0x0f, 0x07, 0x26, 0x67, 0xef, 0xcc, 0xc9, 0xf6,
+	0xc8, 0xf7, 0xb8, 0x47, 0xd2, 0xa3, 0x5e, 0xed, 0xe6, 0xc0, 0xf3, 0x06, 0x0e, 0x6b, 0x98, 0x23,
+	0xbb, 0x61, 0xba, 0xae, 0xc7, 0x4d, 0x6e, 0x7b, 0x6e, 0x20, 0x25, 0x6a, 0xeb, 0x8a, 0x2b, 0x76,
+	0xbd, 0xf1, 0x69, 0xc3, 0x1a, 0xfb, 0x42, 0x40, 0xf1, 0xbf, 0x99, 0xe5, 0xb3, 0xe1, 0x88, 0x2b,
+	0xf8, 0xda, 0xed, 0x59, 0x26, 0xb7, 0x87, 0x2c, 0xe0, 0xe6, 0x70, 0x24, 0x05, 0xe8, 0x7f, 0xf2,
+	0x50, 0x38, 0x60, 0x41, 0x60, 0x0e, 0x18, 0xa9, 0x40, 0xda, 0xb6, 0x74, 0x6d, 0x43, 0xab, 0x97,
+	0x8c, 0xb4, 0x6d, 0x11, 0x02, 0x59, 0xce, 0xde, 0x73, 0x3d, 0x2d, 0x28, 0x62, 0x8d, 0x34, 0xdf,
+	0xf3, 0x86, 0x7a, 0x46, 0xd2, 0x70, 0x4d, 0xaa, 0x90, 0x09, 0xd8, 0x4f, 0x7a, 0x76, 0x43, 0xab,
+	0x67, 0x0d, 0x5c, 0x92, 0x5f, 0x42, 0x29, 0xba, 0x48, 0xcf, 0x6d, 0x68, 0xf5, 0xf2, 0x4e, 0x6d,
+	0x5b, 0xaa, 0xb2, 0x1d, 0xaa, 0xb2, 0x7d, 0x1c, 0x4a, 0x18, 0x53, 0x61, 0xb2, 0x0e, 0xe0, 0xb3,
+	0xbe, 0x3d, 0xb2, 0x99, 0xcb, 0x03, 0x3d, 0xbf, 0x91, 0xa9, 0x97, 0x8c, 0x18, 0x85, 0x6c, 0x41,
+	0x71, 0xe4, 0xb3, 0x80, 0xb9, 0x7d, 0xa6, 0x17, 0x04, 0xf0, 0xd2, 0xf6, 0xa8, 0xb7, 0xfd, 0xa3,
+	0xa2, 0xb5, 0x53, 0x46, 0xc4, 0x27, 0xbf, 0x86, 0x6b, 0xae, 0xdd, 0x3f, 0x77, 0xcd, 0x21, 0xeb,
+	0xf6, 0xcf, 0x4c, 0x77, 0xc0, 0x74, 0x10, 0x47, 0x08, 0x1e, 0xe9, 0x28, 0xd6, 0xae, 0xe0, 0xb4,
+	0x53, 0x46, 0xc5, 0x4d, 0x50, 0xc8, 0xb7, 0x90, 0x65, 0x96, 0xcd, 0xf5, 0x8a, 0x38, 0x73, 0x0d,
+	0xcf, 0x28, 0x4f, 0xb5, 0x2c, 0x9b, 0xb7, 0x53, 0x86, 0x60, 0x93, 0xef, 0x21, 0x6f, 0x31, 0x87,
+	0x71, 0xa6, 0x5f, 0x13, 0x82, 0x2b, 0x31, 0xc1, 0x3d, 0xc1, 0x68, 0xa7, 0x0c, 0x25, 0x42, 0x36,
+	0x21, 0xcf, 0x27, 0x23, 0xdb, 0x1d, 0xe8, 0x55, 0x21, 0x0c, 0x28, 0x7c, 0x2c, 0x28, 0x28, 0x25,
+	0x79, 0xe4, 0x11, 0xc0, 0xd0, 0xb3, 0x98, 0x0c, 0xb3, 0xbe, 0x26, 0x24, 0x2b, 0x02, 0x36, 0xa2,
+	0xb6, 0x53, 0x46, 0x4c, 0x86, 0xdc, 0x81, 0x25, 0xcb, 0x0e, 0x46, 0x8e, 0x39, 0xe9, 0xa2, 0x05,
+	0x7a, 0x51, 0x84, 0xa7, 0xac, 0x68, 0x1d, 0x73, 0xc8, 0xc8, 0x2d, 0x00, 0xf3, 0xc2, 0xe4, 0xa6,
+	0xdf, 0x1d, 0xfb, 0x8e, 0x5e, 0x12, 0x02, 0x25, 0x49, 0x39, 0xf1, 0x1d, 0x64, 0x0f, 0xa5, 0xd2,
+	0x5d, 0xdb, 0xd2, 0xcb, 0x92, 0xad, 0x28, 0xfb, 0x16, 0xf9, 0x05, 0x94, 0xd0, 0x5a, 0x66, 0x75,
+	0x4d, 0xae, 0x2f, 0x7d, 0x32, 0xa2, 0x45, 0x29, 0xdc, 0xe4, 0x44, 0x87, 0x82, 0xb4, 0xdd, 0xd2,
+	0x97, 0x37, 0xb4, 0x7a, 0xd1, 0x08, 0xb7, 0x18, 0xca, 0xa1, 0xe9, 0x9f, 0x5b, 0xde, 0x3b, 0x57,
+	0x27, 0xd3, 0x50, 0x1e, 0x28, 0x5a, 0x5b, 0x33, 0x22, 0x3e, 0x59, 0x87, 0x6c, 0xdf, 0xb3, 0x98,
+	0x7e, 0x5d, 0xc8, 0x15, 0x51, 0x6e, 0xd7, 0xb3, 0x58, 0x5b, 0x33, 0x04, 0x1d, 0x3d, 0x66, 0x72,
+	0x6e, 0xf6, 0xcf, 0x86, 0xcc, 0xe5, 0xfa, 0xea, 0xd4, 0x63, 0xcd, 0x88, 0xda, 0xd6, 0x8c, 0x98,
+	0x0c, 0x46, 0xc2, 0xf5, 0xb8, 0xdd, 0x67, 0xfa, 0x8d, 0x69, 0x24, 0x3a, 0x82, 0xd2, 0xd6, 0x0c,
+	0xc5, 0x23, 0xf7, 0x21, 0x1f, 0xf4, 0xcf, 0xd8, 0xd0, 0xd4, 0x57, 0x36, 0xb4, 0x7a, 0x45, 0x06,
+	0xf7, 0x48, 0x50, 0x5e, 0x33, 0x3f, 0xb0, 0x3d, 0xd7, 0x50, 0x02, 0x64, 0x0d, 0xf2, 0xe6, 0x98,
+	0x9f, 0x79, 0xbe, 0xfe, 0x95, 0x70, 0x9e, 0xda, 0x3d, 0x2f, 0x40, 0x8e, 0x5d, 0x30, 0x97, 0x3f,
+	0x2f, 0x41, 0xa1, 0xef, 0xb9, 0x9c, 0xb9, 0x9c, 0x52, 0x28, 0x86, 0x66, 0xe2, 0xb9, 0xc0, 0x1b,
+	0xfb, 0x7d, 0xa6, 0x32, 0x4f, 0xed, 0xe8, 0x53, 0xc8, 0xa2, 0x89, 0xa4, 0x06, 0x45, 0xc7, 0x74,
+	0x07, 0x63, 0x73, 0x10, 0x4a, 0x44, 0xfb, 0xd8, 0xd9, 0x74, 0xe2, 0xec, 0x04, 0x60, 0x6a, 0xf8,
+	0xbc, 0xbc, 0x16, 0x8f, 0x44, 0xe5, 0x35, 0xae, 0xf1, 0x01, 0x29, 0xe5, 0xba, 0x7c, 0x32, 0x62,
+	0x2a, 0xbf, 0xcb, 0x8a, 0x76, 0x3c, 0x19, 0x31, 0x3c, 0x16, 0xd8, 0x7f, 0x62, 0x22, 0xcf, 0x33,
+	0x86, 0x58, 0x63, 0xea, 0xe3, 0x6b, 0xca, 0x09, 0x69, 0x5c, 0xd2, 0x13, 0xb8, 0x36, 0xbd, 0x7a,
+	0xf7, 0x6c, 0xec, 0x9e, 0x93, 0x4d, 0xc8, 0xda, 0xee, 0xa9, 0xa7, 0x6b, 0x73, 0xc3, 0x92, 0x32,
+	0x04, 0x97, 0xac, 0x42, 0xd6, 0x32, 0xb9, 0x29, 0xb4, 0x5a, 0x42, 0x2a, 0xee, 0x9e, 0xe7, 0x21,
+	0x3b, 0x32, 0x7d, 0x4e, 0xef, 0xc2, 0xca, 0xf4, 0x8c, 0xc1, 0x7e, 0x1a, 0xb3, 0xe0, 0x92, 0x61,
+	0xd4, 0x82, 0xbc, 0x8c, 0x20, 0xf9, 0x0e, 0x72, 0x0e, 0xbb, 0x60, 0x8e, 0x60, 0x56, 0x76, 0xaa,
+	0xd3, 0xe0, 0x6e, 0xbf, 0x42, 0xba, 0x21, 0xd9, 0xf3, 0x4a, 0x1c, 0x5d, 0x87, 0x9c, 0x90, 0x21,
+	0x45, 0xc8, 0xee, 0x77, 0x5e, 0x1c, 0x56, 0x53, 0xa4, 0x0c, 0x85, 0x37, 0x4d, 0xa3, 0xb3, 0xdf,
+	0xf9, 0xa1, 0xaa, 0xd1, 0x7f, 0x6b, 0x50, 0x0c, 0xeb, 0xcd, 0x3c, 0xdf, 0x8e, 0x03, 0xe6, 0x87,
+	0x80, 0xb8, 0x26, 0x75, 0xc8, 0x05, 0xdc, 0xe4, 0xd2, 0xa9, 0x15, 0x59, 0x7d, 0x42, 0x80, 0xed,
+	0x23, 0xe4, 0x18, 0x52, 0x80, 0x3c, 0x83, 0xb2, 0x63, 0x06, 0xbc, 0x6b, 0xf6, 0xb9, 0x7d, 0x21,
+	0x3d, 0x7d, 0x75, 0x9e, 0x01, 0x8a, 0x37, 0x85, 0x34, 0x59, 0x85, 0x1c, 0x96, 0xe3, 0x40, 0xcf,
+	0x89, 0xaa, 0x29, 0x37, 0xf4, 0x37, 0x90, 0x13, 0x57, 0xa0, 0x0d, 0x27, 0x9d, 0x97, 0x9d, 0xc3,
+	0x37, 0x9d, 0x6a, 0x8a, 0x00, 0xe4, 0x7f, 0x7b, 0xb8, 0xdf, 0x69, 0xed, 0x55, 0x35, 0x34, 0xf3,
+	0x55, 0xeb, 0xc5, 0x71, 0x35, 0x2d, 0x0c, 0xde, 0x7b, 0xd5, 0xaa, 0x66, 0x90, 0xdf, 0xdc, 0x3d,
+	0xde, 0x7f, 0xdd, 0xaa, 0x66, 0xa9, 0x0f, 0x85, 0x1f, 0x7d, 0xef, 0xd4, 0x76, 0x2e, 0x5b, 0x5b,
+	0x83, 0x62, 0x58, 0x34, 0x95, 0xc5, 0xd1, 0x7e, 0xa6, 0xde, 0x64, 0x66, 0xeb, 0xcd, 0x4d, 0x6c,
+	0x24, 0x8e, 0xb4, 0xb1, 0x22, 0x33, 0xda, 0xf0, 0x1c, 0x66, 0x08, 0x2a, 0xed, 0x02, 0x39, 0x62,
+	0x3c, 0x2c, 0xd1, 0x0b, 0xe2, 0xfd, 0x33, 0xae, 0xa7, 0x1b, 0x50, 0x51, 0x46, 0x2d, 0x7a, 0x4c,
+	0x5d, 0xa8, 0x24, 0x5b, 0xc4, 0xa5, 0xeb, 0xef, 0xc0, 0x92, 0xe7, 0x58, 0xdd, 0x19, 0x15, 0xca,
+	0x9e, 0x63, 0x85, 0x07, 0x13, 0x1a, 0x66, 0x92, 0x1a, 0xd2, 0x09, 0x94, 0x63, 0xfd, 0x64, 0xa6,
+	0x00, 0x6b, 0xb3, 0x05, 0x78, 0x5e, 0x33, 0x4e, 0x14, 0xe5, 0xcc, 0xe7, 0x17, 0x65, 0xba, 0x0d,
+	0xcb, 0x89, 0x0e, 0xf5, 0x89, 0xcb, 0xe9, 0x06, 0xe4, 0x65, 0x93, 0xc2, 0x8a, 0xa3, 0x1a, 0x98,
+	0x26, 0xaa, 0xb9, 0xda, 0xd1, 0x97, 0xb0, 0x2c, 0x25, 0x16, 0xc5, 0x2a, 0x1c, 0x1c, 0xd2, 0xb1,
+	0xc1, 0x61, 0x0a, 0x96, 0x49, 0x80, 0xbd, 0x01, 0x82, 0x2e, 0x51, 0x2a, 0x2e, 0x42, 0x4c, 0xea,
+	0x9c, 0x5e, 0xe4, 0xb0, 0x4c, 0x2c, 0xb5, 0x5b, 0xb0, 0x2a, 0x0d, 0xfe, 0x59, 0xd0, 0xf4, 0xef,
+	0x1a, 0xac, 0x4c, 0x5b, 0xf1, 0x22, 0x10, 0xb4, 0xce, 0xf4, 0x07, 0x2c, 0x8c, 0x99, 0xda, 0x21,
+	0xdd, 0x67, 0x66, 0xe0, 0xb9, 0x4a, 0x35, 0xb5, 0x23, 0xff, 0x0f, 0xc5, 0x70, 0xb4, 0x53, 0x99,
+	0xff, 0xf5, 0xa5, 0x60, 0xee, 0x29, 0x01, 0x23, 0x12, 0x45, 0x5d, 0x7b, 0x93, 0xae, 0x69, 0x59,
+	0x3e, 0x0b, 0x02, 0x51, 0x89, 0x8b, 0x46, 0xa9, 0x37, 0x69, 0x4a, 0x02, 0x7d, 0x0a, 0x4b, 0x27,
+	0x6e, 0xcf, 0x74, 0xaf, 0xc8, 0x21, 0xdb, 0x62, 0x2e, 0xb7, 0xf9, 0x24, 0xcc, 0xa1, 0x70, 0x4f,
+	0xff, 0xa9, 0x01, 0x4c, 0xed, 0x24, 0x0f, 0x21, 0x8f, 0x85, 0xc9, 0x73, 0x55, 0x55, 0xbd, 0x91,
+	0x1c, 0x49, 0xb6, 0x9b, 0x82, 0x69, 0x28, 0xa1, 0x2f, 0xb6, 0xff, 0x11, 0xe4, 0xc6, 0x2e, 0xb7,
+	0x9d, 0xcf, 0x28, 0x7b, 0x52, 0x90, 0x3e, 0x83, 0xbc, 0xbc, 0x13, 0x2b, 0x57, 0xe7, 0xb0, 0xd3,
+	0xaa, 0xa6, 0x70, 0xf5, 0x72, 0x7f, 0xf7, 0x65, 0x55, 0x23, 0x05, 0xc8, 0x3c, 0x6f, 0x76, 0x64,
+	0x59, 0x3b, 0x38, 0x39, 0x56, 0x65, 0xed, 0xa4, 0x23, 0xd6, 0x59, 0xfa, 0x97, 0x0c, 0x94, 0x76,
+	0xcf, 0x4c, 0xfe, 0xc2, 0xc7, 0x44, 0x7d, 0x02, 0xa5, 0x60, 0xdc, 0x0b, 0xfa, 0xbe, 0xdd, 0x63,
+	0xaa, 0x51, 0xad, 0x8a, 0x5e, 0x1f, 0x12, 0x95, 0xff, 0xda, 0x29, 0x63, 0x2a, 0x48, 0xee, 0x41,
+	0x41, 0xbd, 0x0a, 0x61, 0x63, 0x79, 0xa7, 0x1c, 0x1b, 0xfe, 0xda, 0x29, 0x23, 0xe4, 0xe2, 0x90,
+	0x18, 0x7b, 0xe9, 0x6a, 0x48, 0x4c, 0x24, 0x4c, 0x6c, 0xfc, 0x7b, 0xa0, 0x06, 0x4f, 0xe9, 0x87,
+	0x35, 0x14, 0xbd, 0x9c, 0x0e, 0xd1, 0xfc, 0xb9, 0x13, 0xcd, 0x9f, 0x72, 0xd0, 0xd6, 0x51, 0x7e,
+	0xde, 0x2b, 0x8f, 0x8d, 0xa1, 0xdf, 0x42, 0xf6, 0x8f, 0x9e, 0xed, 0xea, 0xf9, 0xe9, 0x68, 0x6b,
+	0x78, 0xde, 0x30, 0x06, 0x8d, 0x6c, 0x72, 0x0f, 0xbb, 0xa8, 0x79, 0x11, 0x4e, 0xda, 0x73, 0xe4,
+	0x24, 0x9f, 0x3c, 0x89, 0x95, 0xb9, 0xe2, 0x54, 0xeb, 0xcb, 0x25, 0x1c, 0xe7, 0xf3, 0x50, 0x12,
+	0x27, 0xa3, 0x53, 0x74, 0x3e, 0xfd, 0x01, 0xaa, 0xb3, 0x7e, 0x8e, 0xea, 0x85, 0x16, 0xab, 0x17,
+	0xb7, 0xa1, 0xec, 0xb3, 0x60, 0x3c, 0x64, 0xdd, 0x53, 0x5f, 0x95, 0x92, 0xac, 0x01, 0x92, 0xf4,
+	0xc2, 0xf7, 0x86, 0xf4, 0x09, 0x64, 0x51, 0xbf, 0x68, 0xc2, 0xd1, 0x62, 0x13, 0x8e, 0x8e, 0xb1,
+	0x1a, 0xf6, 0x98, 0x1f, 0x88, 0x83, 0x39, 0x23, 0xdc, 0xd2, 0x2d, 0x28, 0xe2, 0xa9, 0x57, 0x76,
+	0xc0, 0xc9, 0x7a, 0xd8, 0x44, 0xb5, 0x8d, 0x4c, 0x38, 0x69, 0x0a, 0x93, 0x25, 0x99, 0x3e, 0x86,
+	0x72, 0xcc, 0x03, 0x9f, 0x53, 0xe5, 0xa8, 0x01, 0x95, 0xb6, 0x1d, 0x70, 0xcf, 0x9f, 0x5c, 0x65,
+	0xdb, 0x1a, 0xe4, 0xfb, 0x63, 0x3f, 0xf0, 0x7c, 0x65, 0x96, 0xda, 0x61, 0x57, 0x77, 0xec, 0xa1,
+	0x2d, 0xeb, 0x58, 0xce, 0x90, 0x1b, 0xfa, 0x3b, 0xb8, 0x16, 0x61, 0x06, 0x23, 0xcf, 0x0d, 0xf0,
+	0x2d, 0x16, 0xd5, 0x6b, 0x0b, 0x95, 0x8f, 0x3f, 0x46, 0x23, 0x62, 0xa2, 0x17, 0x5d, 0xf6, 0x9e,
+	0x77, 0x13, 0xd7, 0x01, 0x92, 0x76, 0x05, 0x85, 0xbe, 0x80, 0xa5, 0x57, 0xde, 0xc0, 0x8e, 0x4a,
+	0x46, 0x0d, 0x8a, 0x38, 0xc7, 0xc4, 0x3c, 0x1a, 0xed, 0x91, 0x37, 0x32, 0x83, 0xe0, 0x9d, 0xe7,
+	0x87, 0x75, 0x32, 0xda, 0xd3, 0xb7, 0xb0, 0xac, 0x70, 0x94, 0x8a, 0xab, 0x90, 0xe3, 0xde, 0x39,
+	0x73, 0x15, 0x8a, 0xdc, 0x90, 0x5f, 0x01, 0xb0, 0xf7, 0x23, 0xdb, 0x67, 0x01, 0xb6, 0xb1, 0xf4,
+	0xa7, 0xff, 0x2d, 0x2a, 0xe9, 0x26, 0xa7, 0xf7, 0x60, 0x05, 0xa3, 0x76, 0xe8, 0x3a, 0xb6, 0x7b,
+	0xd5, 0xcb, 0xa1, 0x3b, 0x00, 0x52, 0x48, 0x04, 0x79, 0x13, 0xf2, 0x9e, 0xd8, 0x29, 0x47, 0x25,
+	0xfe, 0x42, 0x1a, 0x8a, 0xb7, 0xd5, 0x84, 0xe5, 0xc4, 0xa4, 0x4f, 0x08, 0x54, 0x8e, 0x76, 0xdb,
+	0xad, 0x83, 0x66, 0x77, 0x3a, 0x48, 0x2d, 0x43, 0x49, 0xd1, 0x5e, 0x3f, 0xae, 0x6a, 0xf1, 0xed,
+	0x4e, 0x35, 0xbd, 0xf5, 0x00, 0xdf, 0xa3, 0xc3, 0xb0, 0xee, 0x1c, 0xb4, 0x0e, 0x9e, 0xb7, 0x0c,
+	0x79, 0xe2, 0xe0, 0x70, 0xaf, 0x65, 0x34, 0x8f, 0x0f, 0x8d, 0xaa, 0x46, 0x4a, 0x90, 0x6b, 0xee,
+	0x1d, 0xec, 0x77, 0xaa, 0xe9, 0x9d, 0xbf, 0x55, 0xa0, 0x8c, 0x9f, 0x08, 0x8e, 0x98, 0x7f, 0x81,
+	0x43, 0xec, 0x4b, 0xc8, 0x06, 0xcc, 0xb5, 0x48, 0x3c, 0x8e, 0xb5, 0xb5, 0x4b, 0x9e, 0x69, 0xe1,
+	0xff, 0x7d, 0xba, 0xfe, 0xe7, 0x7f, 0xfc, 0xeb, 0xaf, 0x69, 0x9d, 0x5e, 0x6f, 0x5c, 0x3c, 0x6e,
+	0x20, 0x4a, 0xc0, 0xfc, 0x0b, 0xe6, 0x37, 0x10, 0xe1, 0xa9, 0xb6, 0x45, 0x4e, 0x62, 0x05, 0x8e,
+	0xcc, 0x2d, 0x6d, 0xb5, 0xf8, 0x3d, 0xf4, 0xae, 0xc0, 0xbb, 0x45, 0xf5, 0x59, 0xbc, 0xf0, 0xd4,
+	0x53, 0x6d, 0xeb, 0x91, 0x46, 0x9a, 0x00, 0x7d, 0x9f, 0xe1, 0x08, 0x8b, 0x8f, 0x38, 0x4a, 0x97,
+	0x5a, 0xb4, 0xa2, 0xb7, 0x05, 0xd0, 0xd7, 0x74, 0x75, 0x06, 0x48, 0xe4, 0x13, 0x6a, 0x76, 0x08,
+	0x25, 0xc7, 0x0e, 0x38, 0x0a, 0x07, 0x64, 0x81, 0x79, 0xb5, 0xa5, 0x10, 0x0f, 0x03, 0x48, 0x6f,
+	0x0a, 0xcc, 0x35, 0x32, 0x17, 0x93, 0xbc, 0x85, 0x22, 0x96, 0x2f, 0xa1, 0xd1, 0x6c, 0xcd, 0x5a,
+	0xe8, 0xbf, 0xef, 0x05, 0xe4, 0xb7, 0x4f, 0xb5, 0x2d, 0xba, 0x31, 0x0f, 0xb5, 0xf1, 0x01, 0x7f,
+	0x3e, 0x36, 0x44, 0x61, 0xec, 0x41, 0x49, 0x14, 0xbe, 0x2f, 0xbb, 0xe2, 0x81, 0xb8, 0xe2, 0x3b,
+	0x7a, 0xe7, 0x2a, 0x7c, 0x81, 0x8b, 0x6e, 0xe9, 0x42, 0xe1, 0x4c, 0xa6, 0x38, 0x11, 0xff, 0x18,
+	0x92, 0x35, 0xa4, 0x76, 0x3d, 0x41, 0x93, 0x09, 0x16, 0x1a, 0x41, 0xee, 0x5e, 0x75, 0x43, 0x88,
+	0x7a, 0x00, 0x39, 0x07, 0xd3, 0x93, 0x88, 0x7f, 0x47, 0xf1, 0x8c, 0xaf, 0xad, 0xc4, 0x28, 0x0a,
+	0x7a, 0x51, 0x18, 0x05, 0x04, 0xea, 0x6b, 0x00, 0x38, 0x51, 0x2e, 0x12, 0x31, 0x1b, 0x5c, 0xca,
+	0xcd, 0x9a, 0xf8, 0xf3, 0x37, 0xcd, 0x44, 0x7a, 0x4b, 0xa0, 0x7e, 0x45, 0x6e, 0xcc, 0xa0, 0xca,
+	0x14, 0x24, 0x27, 0x50, 0x0e, 0xa6, 0x3d, 0x84, 0x2c, 0x68, 0x2a, 0xf2, 0xe1, 0xaa, 0x71, 0x9e,
+	0xde, 0x11, 0x90, 0xdf, 0xd0, 0xb5, 0x19, 0xc8, 0x91, 0xe4, 0xa3, 0xaa, 0x47, 0x00, 0x03, 0xc6,
+	0xd5, 0x01, 0x42, 0x62, 0xa7, 0xe7, 0x22, 0x6e, 0x0a, 0xc4, 0x75, 0x72, 0x73, 0x3e, 0x62, 0xd0,
+	0xf8, 0x60, 0x5b, 0x1f, 0x49, 0x1f, 0xca, 0x6c, 0xda, 0xa5, 0xc9, 0x82, 0xb6, 0x9d, 0x4c, 0xb2,
+	0x86, 0x40, 0xbe, 0xbf, 0xb3, 0x39, 0x83, 0x1c, 0xd6, 0xea, 0xc6, 0x87, 0xe9, 0x18, 0xfa, 0x11,
+	0x35, 0xf7, 0x60, 0xd9, 0x8a, 0xb7, 0x76, 0xb2, 0xb0, 0xdb, 0x7f, 0xea, 0x15, 0x6e, 0x7d, 0xd6,
+	0x9d, 0x84, 0x41, 0x29, 0x60, 0x5c, 0x0d, 0xff, 0x97, 0xa7, 0x96, 0x85, 0xb7, 0x3c, 0x14, 0xb7,
+	0xdc, 0xa3, 0xf4, 0xaa, 0x97, 0x28, 0xe7, 0x1d, 0xb4, 0xeb, 0x19, 0x54, 0xc7, 0x23, 0xc7, 0x33,
+	0xad, 0xd8, 0x67, 0x8b, 0xeb, 0xc9, 0x0f, 0x05, 0xe2, 0x5b, 0x42, 0x6d, 0xe6, 0xeb, 0x01, 0x4d,
+	0xd5, 0x35, 0xb2, 0x07, 0x04, 0xbf, 0xa4, 0xcc, 0x1c, 0xbf, 0x91, 0x94, 0x4c, 0xe4, 0xcd, 0x0c,
+	0x2a, 0x4d, 0x3d, 0xd2, 0xc8, 0x5b, 0xc8, 0x9e, 0xdb, 0xfd, 0x73, 0x32, 0x33, 0xd5, 0x7e, 0xca,
+	0xd0, 0xfb, 0xc2, 0xd0, 0xbb, 0x58, 0x37, 0xd6, 0x67, 0x3d, 0x1a, 0x81, 0x34, 0x04, 0xf2, 0x1f,
+	0x20, 0xd3, 0x33, 0xdd, 0x2f, 0xbd, 0xa0, 0x2e, 0x2e, 0xa0, 0xf4, 0xd6, 0x62, 0xf4, 0x9e, 0x29,
+	0x32, 0xf0, 0xf7, 0x38, 0x40, 0xf7, 0x4c, 0x95, 0xd0, 0xf1, 0xa9, 0x7f, 0x21, 0xf8, 0x96, 0x00,
+	0xdf, 0xa4, 0xb7, 0x17, 0x83, 0x8f, 0x5d, 0x05, 0xff, 0x16, 0xb2, 0xc3, 0x31, 0x67, 0xff, 0xa3,
+	0x83, 0xae, 0xf2, 0x0e, 0xc2, 0xe2, 0x0d, 0x75, 0xc8, 0xa2, 0x00, 0x59, 0x16, 0xdf, 0xf7, 0xc2,
+	0xd9, 0x3c, 0x99, 0x32, 0xa9, 0xba, 0xf6, 0x48, 0xdb, 0xd9, 0x85, 0xe5, 0x9e, 0xef, 0x9d, 0x33,
+	0x3f, 0xec, 0x95, 0x3b, 0x50, 0x38, 0xf5, 0xfc, 0x77, 0xa6, 0xff, 0x99, 0xed, 0x32, 0x55, 0xd7,
+	0x7a, 0x79, 0x41, 0xfb, 0xbf, 0xff, 0x0e, 0x00, 0x25, 0xed, 0x7c, 0xd3, 0x98, 0x17, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ChatServiceClient is the client API for ChatService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ChatServiceClient interface {
+	Send(ctx context.Context, in *Message, opts ...grpc.CallOption) (*empty.Empty, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (ChatService_SubscribeClient, error)
+	CreateRoom(ctx context.Context, in *Room, opts ...grpc.CallOption) (*Room, error)
+	ListRooms(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RoomList, error)
+	JoinRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	LeaveRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	ListOnline(ctx context.Context, in *ListOnlineRequest, opts ...grpc.CallOption) (*OnlineList, error)
+	SetNickname(ctx context.Context, in *SetNicknameRequest, opts ...grpc.CallOption) (*Profile, error)
+	GetProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*Profile, error)
+	EditMessage(ctx context.Context, in *EditMessageRequest, opts ...grpc.CallOption) (*Message, error)
+	DeleteMessage(ctx context.Context, in *DeleteMessageRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	SetTyping(ctx context.Context, in *TypingRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// uploadAttachment and downloadAttachment are served over HTTP by
+	// custom gateway routes taking multipart uploads and raw downloads.
+	UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (ChatService_UploadAttachmentClient, error)
+	DownloadAttachment(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (ChatService_DownloadAttachmentClient, error)
+	Kick(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Ban(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Mute(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// chat does the work of subscribe and the calls acting for a session on
+	// a single stream, which is the session: the first frame subscribes and
+	// every later one acts for it. A frame that fails is answered with a
+	// warning notice rather than ending the stream. HTTP clients keep using
+	// subscribe and the unary calls.
+	Chat(ctx context.Context, opts ...grpc.CallOption) (ChatService_ChatClient, error)
+}
+
+type chatServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewChatServiceClient(cc *grpc.ClientConn) ChatServiceClient {
+	return &chatServiceClient{cc}
+}
+
+func (c *chatServiceClient) Send(ctx context.Context, in *Message, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/send", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (ChatService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ChatService_serviceDesc.Streams[0], "/pb.chatService/subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ChatService_SubscribeClient interface {
+	Recv() (*Message, error)
+	grpc.ClientStream
+}
+
+type chatServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceSubscribeClient) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatServiceClient) CreateRoom(ctx context.Context, in *Room, opts ...grpc.CallOption) (*Room, error) {
+	out := new(Room)
+	err := c.cc.Invoke(ctx, "/pb.chatService/createRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) ListRooms(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RoomList, error) {
+	out := new(RoomList)
+	err := c.cc.Invoke(ctx, "/pb.chatService/listRooms", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) JoinRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/joinRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) LeaveRoom(ctx context.Context, in *RoomRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/leaveRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, "/pb.chatService/history", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, "/pb.chatService/login", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) ListOnline(ctx context.Context, in *ListOnlineRequest, opts ...grpc.CallOption) (*OnlineList, error) {
+	out := new(OnlineList)
+	err := c.cc.Invoke(ctx, "/pb.chatService/listOnline", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) SetNickname(ctx context.Context, in *SetNicknameRequest, opts ...grpc.CallOption) (*Profile, error) {
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, "/pb.chatService/setNickname", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) GetProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*Profile, error) {
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, "/pb.chatService/getProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) EditMessage(ctx context.Context, in *EditMessageRequest, opts ...grpc.CallOption) (*Message, error) {
+	out := new(Message)
+	err := c.cc.Invoke(ctx, "/pb.chatService/editMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) DeleteMessage(ctx context.Context, in *DeleteMessageRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/deleteMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) SetTyping(ctx context.Context, in *TypingRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/setTyping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) UploadAttachment(ctx context.Context, opts ...grpc.CallOption) (ChatService_UploadAttachmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ChatService_serviceDesc.Streams[1], "/pb.chatService/uploadAttachment", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceUploadAttachmentClient{stream}
+	return x, nil
+}
+
+type ChatService_UploadAttachmentClient interface {
+	Send(*AttachmentChunk) error
+	CloseAndRecv() (*Attachment, error)
+	grpc.ClientStream
+}
+
+type chatServiceUploadAttachmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceUploadAttachmentClient) Send(m *AttachmentChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatServiceUploadAttachmentClient) CloseAndRecv() (*Attachment, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Attachment)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatServiceClient) DownloadAttachment(ctx context.Context, in *AttachmentRequest, opts ...grpc.CallOption) (ChatService_DownloadAttachmentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ChatService_serviceDesc.Streams[2], "/pb.chatService/downloadAttachment", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceDownloadAttachmentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ChatService_DownloadAttachmentClient interface {
+	Recv() (*AttachmentChunk, error)
+	grpc.ClientStream
+}
+
+type chatServiceDownloadAttachmentClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceDownloadAttachmentClient) Recv() (*AttachmentChunk, error) {
+	m := new(AttachmentChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatServiceClient) Kick(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/kick", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Ban(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/ban", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Unban(ctx context.Context, in *UnbanRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/unban", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Mute(ctx context.Context, in *ModerationRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.chatService/mute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatServiceClient) Chat(ctx context.Context, opts ...grpc.CallOption) (ChatService_ChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ChatService_serviceDesc.Streams[3], "/pb.chatService/chat", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceChatClient{stream}
+	return x, nil
+}
+
+type ChatService_ChatClient interface {
+	Send(*ChatFrame) error
+	Recv() (*Message, error)
+	grpc.ClientStream
+}
+
+type chatServiceChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceChatClient) Send(m *ChatFrame) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatServiceChatClient) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ChatServiceServer is the server API for ChatService service.
+type ChatServiceServer interface {
+	Send(context.Context, *Message) (*empty.Empty, error)
+	Subscribe(*SubscribeRequest, ChatService_SubscribeServer) error
+	CreateRoom(context.Context, *Room) (*Room, error)
+	ListRooms(context.Context, *empty.Empty) (*RoomList, error)
+	JoinRoom(context.Context, *RoomRequest) (*empty.Empty, error)
+	LeaveRoom(context.Context, *RoomRequest) (*empty.Empty, error)
+	History(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	ListOnline(context.Context, *ListOnlineRequest) (*OnlineList, error)
+	SetNickname(context.Context, *SetNicknameRequest) (*Profile, error)
+	GetProfile(context.Context, *ProfileRequest) (*Profile, error)
+	EditMessage(context.Context, *EditMessageRequest) (*Message, error)
+	DeleteMessage(context.Context, *DeleteMessageRequest) (*empty.Empty, error)
+	SetTyping(context.Context, *TypingRequest) (*empty.Empty, error)
+	// uploadAttachment and downloadAttachment are served over HTTP by
+	// custom gateway routes taking multipart uploads and raw downloads.
+	UploadAttachment(ChatService_UploadAttachmentServer) error
+	DownloadAttachment(*AttachmentRequest, ChatService_DownloadAttachmentServer) error
+	Kick(context.Context, *ModerationRequest) (*empty.Empty, error)
+	Ban(context.Context, *ModerationRequest) (*empty.Empty, error)
+	Unban(context.Context, *UnbanRequest) (*empty.Empty, error)
+	Mute(context.Context, *ModerationRequest) (*empty.Empty, error)
+	// chat does the work of subscribe and the calls acting for a session on
+	// a single stream, which is the session: the first frame subscribes and
+	// every later one acts for it. A frame that fails is answered with a
+	// warning notice rather than ending the stream. HTTP clients keep using
+	// subscribe and the unary calls.
+	Chat(ChatService_ChatServer) error
+}
+
+func RegisterChatServiceServer(s *grpc.Server, srv ChatServiceServer) {
+	s.RegisterService(&_ChatService_serviceDesc, srv)
+}
+
+func _ChatService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Message)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Send",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Send(ctx, req.(*Message))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).Subscribe(m, &chatServiceSubscribeServer{stream})
+}
+
+type ChatService_SubscribeServer interface {
+	Send(*Message) error
+	grpc.ServerStream
+}
+
+type chatServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceSubscribeServer) Send(m *Message) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ChatService_CreateRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Room)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).CreateRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/CreateRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).CreateRoom(ctx, req.(*Room))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_ListRooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).ListRooms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/ListRooms",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).ListRooms(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_JoinRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).JoinRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/JoinRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).JoinRoom(ctx, req.(*RoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_LeaveRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).LeaveRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/LeaveRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).LeaveRoom(ctx, req.(*RoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/History",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).History(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_ListOnline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOnlineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).ListOnline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/ListOnline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).ListOnline(ctx, req.(*ListOnlineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_SetNickname_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetNicknameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).SetNickname(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/SetNickname",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).SetNickname(ctx, req.(*SetNicknameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_GetProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).GetProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/GetProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).GetProfile(ctx, req.(*ProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_EditMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).EditMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/EditMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).EditMessage(ctx, req.(*EditMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_DeleteMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).DeleteMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/DeleteMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).DeleteMessage(ctx, req.(*DeleteMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_SetTyping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TypingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).SetTyping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/SetTyping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).SetTyping(ctx, req.(*TypingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_UploadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServiceServer).UploadAttachment(&chatServiceUploadAttachmentServer{stream})
+}
+
+type ChatService_UploadAttachmentServer interface {
+	SendAndClose(*Attachment) error
+	Recv() (*AttachmentChunk, error)
+	grpc.ServerStream
+}
+
+type chatServiceUploadAttachmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceUploadAttachmentServer) SendAndClose(m *Attachment) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatServiceUploadAttachmentServer) Recv() (*AttachmentChunk, error) {
+	m := new(AttachmentChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ChatService_DownloadAttachment_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachmentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).DownloadAttachment(m, &chatServiceDownloadAttachmentServer{stream})
+}
+
+type ChatService_DownloadAttachmentServer interface {
+	Send(*AttachmentChunk) error
+	grpc.ServerStream
+}
+
+type chatServiceDownloadAttachmentServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceDownloadAttachmentServer) Send(m *AttachmentChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ChatService_Kick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Kick(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Kick",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Kick(ctx, req.(*ModerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Ban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Ban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Ban",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Ban(ctx, req.(*ModerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Unban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Unban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Unban",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Unban(ctx, req.(*UnbanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Mute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModerationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServiceServer).Mute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.chatService/Mute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServiceServer).Mute(ctx, req.(*ModerationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatService_Chat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServiceServer).Chat(&chatServiceChatServer{stream})
+}
+
+type ChatService_ChatServer interface {
+	Send(*Message) error
+	Recv() (*ChatFrame, error)
+	grpc.ServerStream
+}
+
+type chatServiceChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceChatServer) Send(m *Message) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatServiceChatServer) Recv() (*ChatFrame, error) {
+	m := new(ChatFrame)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _ChatService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.chatService",
+	HandlerType: (*ChatServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "send",
+			Handler:    _ChatService_Send_Handler,
+		},
+		{
+			MethodName: "createRoom",
+			Handler:    _ChatService_CreateRoom_Handler,
+		},
+		{
+			MethodName: "listRooms",
+			Handler:    _ChatService_ListRooms_Handler,
+		},
+		{
+			MethodName: "joinRoom",
+			Handler:    _ChatService_JoinRoom_Handler,
+		},
+		{
+			MethodName: "leaveRoom",
+			Handler:    _ChatService_LeaveRoom_Handler,
+		},
+		{
+			MethodName: "history",
+			Handler:    _ChatService_History_Handler,
+		},
+		{
+			MethodName: "login",
+			Handler:    _ChatService_Login_Handler,
+		},
+		{
+			MethodName: "listOnline",
+			Handler:    _ChatService_ListOnline_Handler,
+		},
+		{
+			MethodName: "setNickname",
+			Handler:    _ChatService_SetNickname_Handler,
+		},
+		{
+			MethodName: "getProfile",
+			Handler:    _ChatService_GetProfile_Handler,
+		},
+		{
+			MethodName: "editMessage",
+			Handler:    _ChatService_EditMessage_Handler,
+		},
+		{
+			MethodName: "deleteMessage",
+			Handler:    _ChatService_DeleteMessage_Handler,
+		},
+		{
+			MethodName: "setTyping",
+			Handler:    _ChatService_SetTyping_Handler,
+		},
+		{
+			MethodName: "kick",
+			Handler:    _ChatService_Kick_Handler,
+		},
+		{
+			MethodName: "ban",
+			Handler:    _ChatService_Ban_Handler,
+		},
+		{
+			MethodName: "unban",
+			Handler:    _ChatService_Unban_Handler,
+		},
+		{
+			MethodName: "mute",
+			Handler:    _ChatService_Mute_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "subscribe",
+			Handler:       _ChatService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "uploadAttachment",
+			Handler:       _ChatService_UploadAttachment_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "downloadAttachment",
+			Handler:       _ChatService_DownloadAttachment_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "chat",
+			Handler:       _ChatService_Chat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "chat-gateway.proto",
+}
+
+// BrokerServiceClient is the client API for BrokerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BrokerServiceClient interface {
+	Forward(ctx context.Context, opts ...grpc.CallOption) (BrokerService_ForwardClient, error)
+}
+
+type brokerServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBrokerServiceClient(cc *grpc.ClientConn) BrokerServiceClient {
+	return &brokerServiceClient{cc}
+}
+
+func (c *brokerServiceClient) Forward(ctx context.Context, opts ...grpc.CallOption) (BrokerService_ForwardClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_BrokerService_serviceDesc.Streams[0], "/pb.brokerService/forward", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &brokerServiceForwardClient{stream}
+	return x, nil
+}
+
+type BrokerService_ForwardClient interface {
+	Send(*Message) error
+	CloseAndRecv() (*empty.Empty, error)
+	grpc.ClientStream
+}
+
+type brokerServiceForwardClient struct {
+	grpc.ClientStream
+}
+
+func (x *brokerServiceForwardClient) Send(m *Message) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *brokerServiceForwardClient) CloseAndRecv() (*empty.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(empty.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BrokerServiceServer is the server API for BrokerService service.
+type BrokerServiceServer interface {
+	Forward(BrokerService_ForwardServer) error
+}
+
+func RegisterBrokerServiceServer(s *grpc.Server, srv BrokerServiceServer) {
+	s.RegisterService(&_BrokerService_serviceDesc, srv)
+}
+
+func _BrokerService_Forward_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BrokerServiceServer).Forward(&brokerServiceForwardServer{stream})
+}
+
+type BrokerService_ForwardServer interface {
+	SendAndClose(*empty.Empty) error
+	Recv() (*Message, error)
+	grpc.ServerStream
+}
+
+type brokerServiceForwardServer struct {
+	grpc.ServerStream
+}
+
+func (x *brokerServiceForwardServer) SendAndClose(m *empty.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *brokerServiceForwardServer) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _BrokerService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.brokerService",
+	HandlerType: (*BrokerServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "forward",
+			Handler:       _BrokerService_Forward_Handler,
+			ClientStreams: true,
 		},
 	},
 	Metadata: "chat-gateway.proto",
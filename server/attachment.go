@@ -0,0 +1,159 @@
+package main
+
+import (
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+)
+
+const (
+	DefaultMaxAttachmentSize = 10 << 20
+	attachmentChunkSize      = 32 << 10
+)
+
+var (
+	ErrAttachmentsDisabled = status.Error(codes.Unimplemented, "[attachment] the server does not store attachments")
+	ErrAttachmentNotFound  = status.Error(codes.NotFound, "[attachment] no such attachment")
+	ErrAttachmentTooLarge  = status.Error(codes.ResourceExhausted, "[attachment] attachment exceeds the size limit")
+	ErrMissingAttachment   = status.Error(codes.InvalidArgument, "[attachment] the first chunk must carry the attachment info")
+)
+
+// chunkReader reads the data of the chunks of an upload.
+type chunkReader struct {
+	stream pb.ChatService_UploadAttachmentServer
+	buf    []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.GetData()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (s *ChatServer) UploadAttachment(stream pb.ChatService_UploadAttachmentServer) error {
+	if s.Blobs == nil {
+		return ErrAttachmentsDisabled
+	}
+	sess, err := s.SessionFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	if err := s.checkVoice(stream.Context(), sess); err != nil {
+		return err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil || info.Name == "" {
+		return ErrMissingAttachment
+	}
+	id, size, err := s.Blobs.Put(&chunkReader{stream: stream}, s.MaxAttachmentSize)
+	if err == ErrBlobTooLarge {
+		return ErrAttachmentTooLarge
+	} else if err != nil {
+		return err
+	}
+	contentType := info.ContentType
+	if contentType == "" {
+		if contentType, err = s.sniff(id); err != nil {
+			return err
+		}
+	}
+	return stream.SendAndClose(&pb.Attachment{
+		Id:          id,
+		Name:        info.Name,
+		ContentType: contentType,
+		Size:        size,
+	})
+}
+
+func (s *ChatServer) DownloadAttachment(req *pb.AttachmentRequest, stream pb.ChatService_DownloadAttachmentServer) error {
+	if s.Blobs == nil {
+		return ErrAttachmentsDisabled
+	}
+	if _, err := s.SessionFromContext(stream.Context()); err != nil {
+		return err
+	}
+	contentType, err := s.sniff(req.Id)
+	if err != nil {
+		return err
+	}
+	blob, size, err := s.Blobs.Open(req.Id)
+	if err == ErrBlobNotFound {
+		return ErrAttachmentNotFound
+	} else if err != nil {
+		return err
+	}
+	defer blob.Close()
+	if err := stream.Send(&pb.AttachmentChunk{Part: &pb.AttachmentChunk_Info{Info: &pb.Attachment{
+		Id:          req.Id,
+		ContentType: contentType,
+		Size:        size,
+	}}}); err != nil {
+		return err
+	}
+	buf := make([]byte, attachmentChunkSize)
+	for {
+		n, err := blob.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.AttachmentChunk{Part: &pb.AttachmentChunk_Data{Data: buf[:n]}}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// sniff guesses the content type of a stored blob from its first bytes, as
+// the blob store keeps nothing but the content.
+func (s *ChatServer) sniff(id string) (string, error) {
+	blob, _, err := s.Blobs.Open(id)
+	if err == ErrBlobNotFound {
+		return "", ErrAttachmentNotFound
+	} else if err != nil {
+		return "", err
+	}
+	defer blob.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(blob, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// resolveAttachment checks that an attachment sent by id was uploaded and
+// fills in its size.
+func (s *ChatServer) resolveAttachment(msg *pb.Message) error {
+	a := msg.GetAttachment()
+	if a == nil || a.Id == "" {
+		return nil
+	}
+	if s.Blobs == nil {
+		return ErrAttachmentsDisabled
+	}
+	blob, size, err := s.Blobs.Open(a.Id)
+	if err == ErrBlobNotFound {
+		return ErrAttachmentNotFound
+	} else if err != nil {
+		return err
+	}
+	blob.Close()
+	a.Size = size
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"strings"
+	"time"
+)
+
+// Authenticator checks user credentials for the Login RPC. Plug in your own
+// to log in against another user backend.
+type Authenticator interface {
+	// Authenticate returns the name the user is known by, or an error when
+	// the credentials are not valid.
+	Authenticate(ctx context.Context, username, password string) (string, error)
+}
+
+var (
+	ErrAuthDisabled       = status.Error(codes.Unimplemented, "[auth] authentication is not enabled")
+	ErrBadCredentials     = status.Error(codes.Unauthenticated, "[auth] invalid username or password")
+	ErrMissingCredentials = status.Error(codes.Unauthenticated, "[auth] missing bearer token")
+	ErrBadAccessToken     = status.Error(codes.Unauthenticated, "[auth] invalid or expired bearer token")
+	ErrUserMismatch       = status.Error(codes.PermissionDenied, "[auth] session belongs to another user")
+)
+
+// FileAuthenticator reads users from a file with one "name:sha256-hex of the
+// password" entry per line. Empty lines and lines starting with # are skipped.
+type FileAuthenticator struct {
+	users map[string][]byte
+}
+
+func NewFileAuthenticator(path string) (*FileAuthenticator, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	a := &FileAuthenticator{users: make(map[string][]byte)}
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, ":")
+		if i <= 0 {
+			return nil, errors.New("[auth] malformed line in " + path)
+		}
+		sum, err := hex.DecodeString(line[i+1:])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, errors.New("[auth] malformed password hash in " + path)
+		}
+		a.users[line[:i]] = sum
+	}
+	return a, scanner.Err()
+}
+
+func (a *FileAuthenticator) Authenticate(ctx context.Context, username, password string) (string, error) {
+	want, ok := a.users[username]
+	sum := sha256.Sum256([]byte(password))
+	if !ok || subtle.ConstantTimeCompare(want, sum[:]) != 1 {
+		return "", ErrBadCredentials
+	}
+	return username, nil
+}
+
+// TokenIssuer signs self-contained access tokens with HMAC-SHA256, so they
+// can be checked without any lookup. Tokens are "<claims>.<signature>", both
+// base64url encoded.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+type tokenClaims struct {
+	Subject string `json:"sub"`
+	Expires int64  `json:"exp"`
+}
+
+// NewTokenIssuer signs with secret, or with a random one when it is empty,
+// in which case tokens do not survive a restart.
+func NewTokenIssuer(secret []byte, ttl time.Duration) *TokenIssuer {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	return &TokenIssuer{secret: secret, ttl: ttl}
+}
+
+func (t *TokenIssuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (t *TokenIssuer) Issue(user string) (string, time.Time, error) {
+	expires := time.Now().Add(t.ttl)
+	claims, err := json.Marshal(&tokenClaims{
+		Subject: user,
+		Expires: expires.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + t.sign(payload), expires, nil
+}
+
+// Verify returns the user a valid, unexpired token was issued to.
+func (t *TokenIssuer) Verify(token string) (string, error) {
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return "", ErrBadAccessToken
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(t.sign(payload))) {
+		return "", ErrBadAccessToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrBadAccessToken
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return "", ErrBadAccessToken
+	}
+	if claims.Subject == "" || time.Now().Unix() >= claims.Expires {
+		return "", ErrBadAccessToken
+	}
+	return claims.Subject, nil
+}
+
+type userKey struct{}
+
+// UserFromContext returns the user authenticated by the interceptors.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok
+}
+
+// authenticate checks the bearer token of the call and stores its user in
+// the returned context.
+func (s *ChatServer) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, ErrMissingCredentials
+	}
+	const prefix = "bearer "
+	if len(values[0]) <= len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return nil, ErrMissingCredentials
+	}
+	user, err := s.Tokens.Verify(values[0][len(prefix):])
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, userKey{}, user), nil
+}
+
+func (s *ChatServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	if s.Authenticator == nil {
+		return nil, ErrAuthDisabled
+	}
+	user, err := s.Authenticator.Authenticate(ctx, req.Username, req.Password)
+	if err != nil {
+		s.Logger.Warn("failed login", "event", "login", "user", req.Username, "peer", peerAddr(ctx))
+		return nil, err
+	}
+	token, expires, err := s.Tokens.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := ptypes.TimestampProto(expires)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
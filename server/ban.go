@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Ban keeps a user name or certificate identity, or an address, from
+// chatting until Until, forever when it is nil.
+type Ban struct {
+	Identity string     `json:"identity,omitempty"`
+	Address  string     `json:"address,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	By       string     `json:"by"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+func (b *Ban) expired(now time.Time) bool {
+	return b.Until != nil && now.After(*b.Until)
+}
+
+// BanList holds the bans of the server, rewriting its file on every change
+// when it has one.
+type BanList struct {
+	path string
+
+	mu   sync.Mutex
+	bans []Ban
+}
+
+// LoadBanList reads the bans kept in path, which need not exist yet. An empty
+// path keeps the bans in memory only.
+func LoadBanList(path string) (*BanList, error) {
+	l := &BanList{path: path}
+	if path == "" {
+		return l, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.bans); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *BanList) Add(ban Ban) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bans = append(l.bans, ban)
+	return l.save()
+}
+
+// Remove lifts every ban on identity, be it a name or an address, and
+// reports whether there was one.
+func (l *BanList) Remove(identity string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kept := l.bans[:0]
+	for _, b := range l.bans {
+		if b.Identity != identity && b.Address != identity {
+			kept = append(kept, b)
+		}
+	}
+	removed := len(kept) < len(l.bans)
+	l.bans = kept
+	if !removed {
+		return false, nil
+	}
+	return true, l.save()
+}
+
+// Banned returns the ban on identity or address, either of which may be
+// empty.
+func (l *BanList) Banned(identity, address string) (*Ban, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for i := range l.bans {
+		b := &l.bans[i]
+		if b.expired(now) {
+			continue
+		}
+		if (identity != "" && b.Identity == identity) || (address != "" && b.Address == address) {
+			ban := *b
+			return &ban, true
+		}
+	}
+	return nil, false
+}
+
+// save writes the bans that have not expired yet through a temporary file,
+// so a crash leaves either the old or the new list. It must be called with
+// l.mu held.
+func (l *BanList) save() error {
+	now := time.Now()
+	kept := l.bans[:0]
+	for _, b := range l.bans {
+		if !b.expired(now) {
+			kept = append(kept, b)
+		}
+	}
+	l.bans = kept
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(l.bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(l.path), filepath.Base(l.path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore keeps attachment contents under the hex SHA-256 of their bytes,
+// so the same file uploaded twice is stored once.
+type BlobStore interface {
+	// Put stores what r yields, failing with ErrBlobTooLarge past limit
+	// bytes, and returns its id and size.
+	Put(r io.Reader, limit int64) (id string, size int64, err error)
+	// Open returns the content stored under id and its size.
+	Open(id string) (io.ReadCloser, int64, error)
+}
+
+var (
+	ErrBlobNotFound = errors.New("[blob] no such blob")
+	ErrBlobTooLarge = errors.New("[blob] blob exceeds the size limit")
+)
+
+// FileBlobStore is a BlobStore keeping each blob in a file named by its id,
+// under a directory named by the first two characters of the id.
+type FileBlobStore struct {
+	Dir string
+}
+
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileBlobStore{Dir: dir}, nil
+}
+
+// validBlobId accepts hex SHA-256 sums only, which keeps ids from naming
+// files outside of the store.
+func validBlobId(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func (f *FileBlobStore) path(id string) string {
+	return filepath.Join(f.Dir, id[:2], id)
+}
+
+func (f *FileBlobStore) Put(r io.Reader, limit int64) (string, int64, error) {
+	tmp, err := ioutil.TempFile(f.Dir, "upload-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, limit+1))
+	if err != nil {
+		return "", 0, err
+	}
+	if size > limit {
+		return "", 0, ErrBlobTooLarge
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	id := hex.EncodeToString(hash.Sum(nil))
+	if _, err := os.Stat(f.path(id)); err == nil {
+		return id, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path(id)), 0755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), f.path(id)); err != nil {
+		return "", 0, err
+	}
+	return id, size, nil
+}
+
+func (f *FileBlobStore) Open(id string) (io.ReadCloser, int64, error) {
+	if !validBlobId(id) {
+		return nil, 0, ErrBlobNotFound
+	}
+	fp, err := os.Open(f.path(id))
+	if os.IsNotExist(err) {
+		return nil, 0, ErrBlobNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+	info, err := fp.Stat()
+	if err != nil {
+		fp.Close()
+		return nil, 0, err
+	}
+	return fp, info.Size(), nil
+}
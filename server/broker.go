@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Broker carries messages between chat server nodes. Run publishes the
+// messages sent on this node and delivers the ones published on the others.
+type Broker interface {
+	// Publish hands msg to the other nodes. It must not block.
+	Publish(msg *pb.Message) error
+	// Messages returns the messages published on the other nodes.
+	Messages() <-chan *pb.Message
+	Close() error
+}
+
+// LocalBroker is the broker of a single node: there is nobody to publish to.
+type LocalBroker struct{}
+
+func (LocalBroker) Publish(*pb.Message) error    { return nil }
+func (LocalBroker) Messages() <-chan *pb.Message { return nil }
+func (LocalBroker) Close() error                 { return nil }
+
+// PeerTokenKey is the metadata key a node sends the token proving it is a
+// peer in when it opens Forward.
+const PeerTokenKey = "x-peer-token"
+
+const (
+	peerQueueSize     = 1000
+	peerRetryInterval = time.Second
+	// peerUser is the subject of the tokens nodes forward messages with,
+	// which stay valid for peerTokenTTL.
+	peerUser     = "#peer"
+	peerTokenTTL = 5 * time.Minute
+)
+
+var (
+	ErrPeerBacklog  = status.Error(codes.Unavailable, "[broker] peer is too far behind, message dropped")
+	ErrNotPeer      = status.Error(codes.PermissionDenied, "[broker] only chat server nodes may forward messages")
+	ErrNoPeerSecret = errors.New("[broker] the nodes need a shared secret to recognize each other")
+)
+
+// PeerBroker links the nodes directly: every node dials all of its peers
+// and forwards what is published on it, so the peers have to form a full
+// mesh. Messages are restamped by the store of every node delivering them.
+// Delivery is best effort, messages published while a peer is unreachable
+// are lost for it. Rooms, presence lists, nicknames and direct message
+// recipients are still known per node. Nodes prove to each other that they
+// are peers with tokens signed by a secret they share, so that clients able
+// to reach Forward cannot inject messages; use TLS between the nodes to keep
+// the tokens from being replayed.
+type PeerBroker struct {
+	Logger *slog.Logger
+
+	tokens   *TokenIssuer
+	peers    []*peerLink
+	messages chan *pb.Message
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+type peerLink struct {
+	addr  string
+	conn  *grpc.ClientConn
+	queue chan *pb.Message
+	// dropped counts the messages lost since the queue filled up, so that
+	// an outage is logged once rather than for every message.
+	dropped int
+}
+
+// NewPeerBroker dials addrs with opts. Every node has to be given the same
+// secret. Register it on the gRPC server of this node and call Start to begin
+// forwarding.
+func NewPeerBroker(addrs []string, secret []byte, opts ...grpc.DialOption) (*PeerBroker, error) {
+	if len(secret) == 0 {
+		return nil, ErrNoPeerSecret
+	}
+	b := &PeerBroker{
+		messages: make(chan *pb.Message, peerQueueSize),
+		tokens:   NewTokenIssuer(secret, peerTokenTTL),
+		Logger:   discardLogger(),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	for _, addr := range addrs {
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		b.peers = append(b.peers, &peerLink{
+			addr:  addr,
+			conn:  conn,
+			queue: make(chan *pb.Message, peerQueueSize),
+		})
+	}
+	return b, nil
+}
+
+func (b *PeerBroker) Start() {
+	for _, p := range b.peers {
+		b.wg.Add(1)
+		go func(p *peerLink) {
+			defer b.wg.Done()
+			b.forward(p)
+		}(p)
+	}
+}
+
+// Publish queues msg for every peer, returning ErrPeerBacklog when the queue
+// of one is full. It is only called from the Run goroutine.
+func (b *PeerBroker) Publish(msg *pb.Message) error {
+	var err error
+	for _, p := range b.peers {
+		select {
+		case p.queue <- msg:
+			if p.dropped > 0 {
+				b.Logger.Info("peer caught up", "event", "broker", "peer", p.addr, "dropped", p.dropped)
+				p.dropped = 0
+			}
+		default:
+			if p.dropped == 0 {
+				b.Logger.Warn("peer is too far behind, dropping messages", "event", "broker", "peer", p.addr)
+			}
+			p.dropped++
+			err = ErrPeerBacklog
+		}
+	}
+	return err
+}
+
+func (b *PeerBroker) Messages() <-chan *pb.Message {
+	return b.messages
+}
+
+func (b *PeerBroker) Close() error {
+	b.cancel()
+	b.wg.Wait()
+	for _, p := range b.peers {
+		p.conn.Close()
+	}
+	return nil
+}
+
+// forward streams the queue of p to it, opening the stream again after
+// peerRetryInterval when it fails.
+func (b *PeerBroker) forward(p *peerLink) {
+	client := pb.NewBrokerServiceClient(p.conn)
+	for {
+		err := b.stream(client, p)
+		if b.ctx.Err() != nil {
+			return
+		}
+		b.Logger.Warn("forwarding failed", "event", "broker", "peer", p.addr, "err", err)
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(peerRetryInterval):
+		}
+	}
+}
+
+func (b *PeerBroker) stream(client pb.BrokerServiceClient, p *peerLink) error {
+	ctx, cancel := context.WithCancel(b.ctx)
+	defer cancel()
+	token, _, err := b.tokens.Issue(peerUser)
+	if err != nil {
+		return err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, PeerTokenKey, token)
+	stream, err := client.Forward(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-p.queue:
+			if err := stream.Send(msg); err != nil {
+				if err == io.EOF {
+					_, err = stream.CloseAndRecv()
+				}
+				return err
+			}
+		}
+	}
+}
+
+// Forward receives the messages a peer forwards to this node, after checking
+// its token.
+func (b *PeerBroker) Forward(stream pb.BrokerService_ForwardServer) error {
+	if !b.isPeer(stream.Context()) {
+		return ErrNotPeer
+	}
+	// Recv does not watch b.ctx, returning closes the stream under it
+	received := make(chan *pb.Message)
+	failed := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case received <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case msg := <-received:
+			select {
+			case b.messages <- msg:
+			case <-b.ctx.Done():
+				return ErrShuttingDown
+			}
+		case err := <-failed:
+			if err == io.EOF {
+				return stream.SendAndClose(&empty.Empty{})
+			}
+			return err
+		case <-b.ctx.Done():
+			return ErrShuttingDown
+		}
+	}
+}
+
+// isPeer reports whether the call carries a valid peer token.
+func (b *PeerBroker) isPeer(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(PeerTokenKey)
+	if len(tokens) == 0 {
+		return false
+	}
+	user, err := b.tokens.Verify(tokens[0])
+	return err == nil && user == peerUser
+}
+
+// UseBroker makes the server publish to and deliver from b. It has to be
+// called before the server takes any calls.
+func (s *ChatServer) UseBroker(b Broker) {
+	s.m.Lock()
+	s.broker = b
+	s.m.Unlock()
+	go func() {
+		for {
+			select {
+			case msg, ok := <-b.Messages():
+				if !ok {
+					return
+				}
+				select {
+				case s.remote <- msg:
+				case <-s.Ctx.Done():
+					return
+				}
+			case <-s.Ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// publish hands msg to the other nodes. It runs on the Run goroutine.
+func (s *ChatServer) publish(msg *pb.Message) {
+	s.m.RLock()
+	b := s.broker
+	s.m.RUnlock()
+	// a peer falling behind is logged by the broker, once per outage
+	switch err := b.Publish(msg); err {
+	case nil:
+	case ErrPeerBacklog:
+		atomic.AddUint64(&s.metrics.peerDropped, 1)
+	default:
+		s.reportError(nil, "broker", err)
+	}
+}
+
+// shared reports whether msg is of interest to the other nodes. Notices from
+// the server itself, such as the shutdown message, stay on this node, except
+// events which are about its sessions.
+func shared(msg *pb.Message) bool {
+	return msg.Id != "" || msg.Event != nil
+}
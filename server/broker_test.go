@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testPeerSecret = []byte("peer secret")
+
+// startNodes serves n ChatServers linked by PeerBrokers and returns their
+// addresses.
+func startNodes(t *testing.T, n int) []string {
+	t.Helper()
+	listeners := make([]net.Listener, n)
+	addrs := make([]string, n)
+	for i := range listeners {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners[i], addrs[i] = lis, lis.Addr().String()
+	}
+	for i, lis := range listeners {
+		var peers []string
+		for j, addr := range addrs {
+			if j != i {
+				peers = append(peers, addr)
+			}
+		}
+		broker, err := NewPeerBroker(peers, testPeerSecret, grpc.WithInsecure())
+		if err != nil {
+			t.Fatalf("NewPeerBroker: %v", err)
+		}
+		gs := NewServer()
+		server := grpc.NewServer()
+		pb.RegisterChatServiceServer(server, gs)
+		pb.RegisterBrokerServiceServer(server, broker)
+		gs.UseBroker(broker)
+		broker.Start()
+		go server.Serve(lis)
+		t.Cleanup(func() {
+			server.Stop()
+			broker.Close()
+			gs.cancel()
+		})
+	}
+	return addrs
+}
+
+func TestPeerBroker(t *testing.T) {
+	addrs := startNodes(t, 2)
+	a, err := dial(t, addrs[0], grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dial(t, addrs[1], grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := open(t, a, &pb.SubscribeRequest{})
+	receiver := open(t, b, &pb.SubscribeRequest{})
+
+	// the forwarding streams may still be connecting, keep sending until
+	// a message gets through, slower than the session rate limit refills
+	received := make(chan string, 1)
+	go func() {
+		for {
+			msg, err := receiver.stream.Recv()
+			if err != nil {
+				return
+			}
+			if msg.Id == sender.id && msg.Text != "" {
+				received <- msg.Text
+				return
+			}
+		}
+	}()
+	deadline := time.After(5 * time.Second)
+	for {
+		if err := sender.send(a, "across"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		select {
+		case text := <-received:
+			if text != "across" {
+				t.Errorf("node B got %q, want \"across\"", text)
+			}
+			return
+		case <-time.After(250 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("a message sent on node A did not reach node B")
+		}
+	}
+}
+
+func TestPeerBrokerRefusesClients(t *testing.T) {
+	addrs := startNodes(t, 1)
+	conn, err := grpc.Dial(addrs[0], grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	stream, err := pb.NewBrokerServiceClient(conn).Forward(ctx)
+	if err == nil {
+		stream.Send(&pb.Message{Id: "intruder", Text: "injected"})
+		_, err = stream.CloseAndRecv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Forward without a peer token returned %v, want PermissionDenied", err)
+	}
+}
+
+func TestPeerBrokerBacklog(t *testing.T) {
+	// the peer is never started, nothing leaves its queue
+	broker, err := NewPeerBroker([]string{"127.0.0.1:1"}, testPeerSecret, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("NewPeerBroker: %v", err)
+	}
+	defer broker.Close()
+	var logs bytes.Buffer
+	broker.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	for i := 0; i < peerQueueSize; i++ {
+		if err := broker.Publish(&pb.Message{Text: "queued"}); err != nil {
+			t.Fatalf("Publish %d: %v", i, err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if err := broker.Publish(&pb.Message{Text: "dropped"}); err != ErrPeerBacklog {
+			t.Fatalf("Publish to a full queue returned %v, want ErrPeerBacklog", err)
+		}
+	}
+	if n := strings.Count(logs.String(), "too far behind"); n != 1 {
+		t.Errorf("the outage was logged %d times, want once", n)
+	}
+
+	<-broker.peers[0].queue
+	if err := broker.Publish(&pb.Message{Text: "queued"}); err != nil {
+		t.Fatalf("Publish after the queue drained: %v", err)
+	}
+	if !strings.Contains(logs.String(), "dropped=10") {
+		t.Errorf("catching up did not log the 10 dropped messages:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+var ErrNotSubscribed = status.Error(codes.FailedPrecondition, "[chat] the first frame must subscribe")
+
+type sessionKey struct{}
+
+// withSession makes SessionFromContext resolve ctx to sess, which is how the
+// frames of a chat stream act for the session of the stream.
+func withSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sess)
+}
+
+func (s *ChatServer) Chat(stream pb.ChatService_ChatServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	req := first.GetSubscribe()
+	if req == nil {
+		return ErrNotSubscribed
+	}
+	return s.serve(req, stream, func(sess *Session) error {
+		go s.readFrames(sess, stream)
+		return sess.writePump()
+	})
+}
+
+// readFrames acts on the frames of stream until the client stops sending,
+// then disconnects sess so that its writePump returns. Every frame takes a
+// token of the rate limits, a client sending faster than they allow loses
+// its stream with ResourceExhausted.
+func (s *ChatServer) readFrames(sess *Session, stream pb.ChatService_ChatServer) {
+	ctx := withSession(stream.Context(), sess)
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF && status.Code(err) != codes.Canceled {
+				s.reportError(sess, "readframes", err)
+			}
+			select {
+			case s.Disconnect <- sess:
+			case <-s.Ctx.Done():
+			}
+			return
+		}
+		if err := s.takeToken(stream.Context(), sess); err != nil {
+			select {
+			case s.evict <- eviction{sess: sess, err: err}:
+			case <-s.Ctx.Done():
+			}
+			return
+		}
+		if room, err := s.handleFrame(ctx, frame); err != nil {
+			s.logger(sess).Debug("frame failed", "event", "readframes", "error", err)
+			msg := notice(room, pb.Notice_WARNING, status.Convert(err).Message())
+			msg.Recipients = []string{sess.Id}
+			s.tell(sess, msg)
+		}
+	}
+}
+
+// tell queues msg for sess alone without going through Run, dropping it when
+// the output of sess is full.
+func (s *ChatServer) tell(sess *Session, msg *pb.Message) {
+	// sessions are closed only after they are removed from Gophers
+	s.m.RLock()
+	defer s.m.RUnlock()
+	if s.Gophers[sess.Id] != sess {
+		return
+	}
+	select {
+	case sess.output <- msg:
+	default:
+		sess.drop()
+	}
+}
+
+// handleFrame acts on frame through the call it stands for, returning the
+// room the frame was about.
+func (s *ChatServer) handleFrame(ctx context.Context, frame *pb.ChatFrame) (string, error) {
+	var err error
+	switch f := frame.Frame.(type) {
+	case *pb.ChatFrame_Message:
+		_, err = s.Send(ctx, f.Message)
+		return f.Message.Room, err
+	case *pb.ChatFrame_Typing:
+		_, err = s.SetTyping(ctx, f.Typing)
+		return f.Typing.Room, err
+	case *pb.ChatFrame_Edit:
+		_, err = s.EditMessage(ctx, f.Edit)
+	case *pb.ChatFrame_Delete:
+		_, err = s.DeleteMessage(ctx, f.Delete)
+	case *pb.ChatFrame_Join:
+		_, err = s.JoinRoom(ctx, f.Join)
+		return f.Join.Room, err
+	case *pb.ChatFrame_Leave:
+		_, err = s.LeaveRoom(ctx, f.Leave)
+		return f.Leave.Room, err
+	case *pb.ChatFrame_Nickname:
+		_, err = s.SetNickname(ctx, f.Nickname)
+	case *pb.ChatFrame_Subscribe:
+		err = status.Error(codes.FailedPrecondition, "[chat] already subscribed")
+	default:
+		err = status.Error(codes.InvalidArgument, "[chat] empty frame")
+	}
+	return "", err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChatFlood(t *testing.T) {
+	addr := startServer(t, nil)
+	client, err := dial(t, addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := open(t, client, &pb.SubscribeRequest{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Chat(ctx)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	subscribe := &pb.ChatFrame{Frame: &pb.ChatFrame_Subscribe{Subscribe: &pb.SubscribeRequest{}}}
+	if err := stream.Send(subscribe); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	// empty frames fail before any call, each of them used to queue a
+	// notice on Broadcast
+	go func() {
+		for {
+			if err := stream.Send(&pb.ChatFrame{}); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := other.send(client, "still here"); err != nil {
+		t.Errorf("Send of another session during the flood: %v", err)
+	}
+	for {
+		_, err := stream.Recv()
+		if err == nil {
+			continue
+		}
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("flooded stream ended with %v, want ResourceExhausted", err)
+		}
+		break
+	}
+	if retry := stream.Trailer().Get(RetryAfterKey); len(retry) == 0 {
+		t.Error("flooded stream ended without a retry-after trailer")
+	}
+
+	// the flood stopped at the session, others may still send
+	if err := other.send(client, "after"); err != nil {
+		t.Errorf("Send of another session after the flood: %v", err)
+	}
+}
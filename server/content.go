@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// CurrentSchema is the version of chat.proto the server broadcasts with.
+const CurrentSchema = pb.SchemaVersion_SCHEMA_V2
+
+const MaxLanguageLength = 32
+
+var (
+	ErrNoticeFromClient  = status.Error(codes.InvalidArgument, "[content] only the server sends notices")
+	ErrEmptyContent      = status.Error(codes.InvalidArgument, "[content] content has nothing to show")
+	ErrInvalidLanguage   = status.Error(codes.InvalidArgument, "[content] code language must be a short name without spaces")
+	ErrInvalidAttachment = status.Error(codes.InvalidArgument, "[content] attachment needs a name and an id or http url")
+)
+
+// checkContent validates the content of a message sent by a client.
+func checkContent(msg *pb.Message) error {
+	switch c := msg.Content.(type) {
+	case nil:
+	case *pb.Message_Markdown:
+		if c.Markdown.Source == "" {
+			return ErrEmptyContent
+		}
+	case *pb.Message_Code:
+		if c.Code.Source == "" {
+			return ErrEmptyContent
+		}
+		if len(c.Code.Language) > MaxLanguageLength || strings.ContainsAny(c.Code.Language, " \t\n") {
+			return ErrInvalidLanguage
+		}
+	case *pb.Message_Attachment:
+		a := c.Attachment
+		if a.Name == "" || (a.Id == "" && a.Url == "") || (a.Url != "" && !validHTTPURL(a.Url)) {
+			return ErrInvalidAttachment
+		}
+	case *pb.Message_Notice:
+		return ErrNoticeFromClient
+	}
+	return nil
+}
+
+// fallbackText is the text of msg, or one made up from its content for
+// clients that do not render it.
+func fallbackText(msg *pb.Message) string {
+	if msg.Text != "" {
+		return msg.Text
+	}
+	switch c := msg.Content.(type) {
+	case *pb.Message_Markdown:
+		return c.Markdown.Source
+	case *pb.Message_Code:
+		return c.Code.Source
+	case *pb.Message_Attachment:
+		return c.Attachment.Name
+	case *pb.Message_Notice:
+		return c.Notice.Text
+	}
+	return ""
+}
+
+// setText replaces the text of msg along with the source of its markdown or
+// code content.
+func setText(msg *pb.Message, text string) {
+	msg.Text = text
+	switch c := msg.Content.(type) {
+	case *pb.Message_Markdown:
+		c.Markdown.Source = text
+	case *pb.Message_Code:
+		c.Code.Source = text
+	}
+}
+
+// notice is a message from the server itself to room.
+func notice(room string, level pb.Notice_Level, text string) *pb.Message {
+	return &pb.Message{
+		Text:    text,
+		Room:    room,
+		Content: &pb.Message_Notice{Notice: &pb.Notice{Level: level, Text: text}},
+		Schema:  CurrentSchema,
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const MaxRecipients = 32
+
+var (
+	ErrRecipientOffline  = status.Error(codes.NotFound, "[direct] recipient is not online")
+	ErrTooManyRecipients = status.Error(codes.InvalidArgument, "[direct] too many recipients")
+)
+
+// sendDirect queues a private message from sender to the sessions listed in
+// msg.Recipients, all of which have to be online.
+func (s *ChatServer) sendDirect(sender *Session, msg *pb.Message) (*empty.Empty, error) {
+	if len(msg.Recipients) > MaxRecipients {
+		return nil, ErrTooManyRecipients
+	}
+	seen := make(map[string]bool, len(msg.Recipients))
+	recipients := make([]string, 0, len(msg.Recipients))
+	for _, id := range msg.Recipients {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := s.SessionByID(id); err != nil {
+			return nil, ErrRecipientOffline
+		}
+		recipients = append(recipients, id)
+	}
+	s.Broadcast <- sender.attribute(&pb.Message{
+		Id:         sender.Id,
+		Text:       fallbackText(msg),
+		Recipients: recipients,
+		MessageId:  generateMessageId(),
+		Content:    msg.Content,
+	})
+	return &empty.Empty{}, nil
+}
+
+// audience returns the sessions msg is delivered to: the members of its room,
+// its recipients and the sender for a direct message, or everyone for a
+// nickname change. It must be called with s.m held.
+func (s *ChatServer) audience(msg *pb.Message) []*Session {
+	if msg.GetNicknameChange() != nil {
+		sessions := make([]*Session, 0, len(s.Gophers))
+		for _, sess := range s.Gophers {
+			sessions = append(sessions, sess)
+		}
+		return sessions
+	}
+	if len(msg.Recipients) == 0 {
+		room, ok := s.Rooms[msg.Room]
+		if !ok {
+			return nil
+		}
+		sessions := make([]*Session, 0, len(room.members))
+		for _, sess := range room.members {
+			sessions = append(sessions, sess)
+		}
+		return sessions
+	}
+	sessions := make([]*Session, 0, len(msg.Recipients)+1)
+	if sess, ok := s.Gophers[msg.Id]; ok {
+		sessions = append(sessions, sess)
+	}
+	for _, id := range msg.Recipients {
+		if sess, ok := s.Gophers[id]; ok && id != msg.Id {
+			sessions = append(sessions, sess)
+		}
+	}
+	return sessions
+}
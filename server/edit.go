@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrNotMessageSender = status.Error(codes.PermissionDenied, "[edit] only the sender may change a message")
+)
+
+func generateMessageId() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ownMessage returns the stored message with messageId after checking that
+// sess sent it and still is in its room.
+func (s *ChatServer) ownMessage(sess *Session, messageId string) (*pb.Message, error) {
+	stored, err := s.Store.Lookup(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if stored.Msg.Deleted {
+		return nil, ErrMessageNotFound
+	}
+	if !s.sentBy(stored.Msg, sess) {
+		return nil, ErrNotMessageSender
+	}
+	if _, err := s.roomOf(sess, stored.Msg.Room); err != nil {
+		return nil, err
+	}
+	return stored.Msg, nil
+}
+
+// sentBy reports whether msg is from the author of sess, which outlives the
+// session for users and certificate identities. Messages stored before they
+// had an author only belong to the session that sent them.
+func (s *ChatServer) sentBy(msg *pb.Message, sess *Session) bool {
+	if msg.Author == "" {
+		return msg.Id == sess.Id
+	}
+	author, _ := s.identity(sess)
+	return msg.Author == author
+}
+
+func (s *ChatServer) EditMessage(ctx context.Context, req *pb.EditMessageRequest) (*pb.Message, error) {
+	if s.closing() {
+		return nil, ErrShuttingDown
+	}
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVoice(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	msg, err := s.ownMessage(sess, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	filtered := &pb.Message{Text: req.Text}
+	if err := s.Filters.Filter(filtered); err != nil {
+		return nil, err
+	}
+	edit := &pb.MessageEdit{
+		MessageId: msg.MessageId,
+		Text:      filtered.Text,
+		EditedAt:  ptypes.TimestampNow(),
+	}
+	s.Broadcast <- sess.attribute(&pb.Message{
+		Id:    sess.Id,
+		Room:  msg.Room,
+		Event: &pb.Message_Edit{Edit: edit},
+	})
+	edited := proto.Clone(msg).(*pb.Message)
+	setText(edited, edit.Text)
+	edited.EditedAt = edit.EditedAt
+	return edited, nil
+}
+
+func (s *ChatServer) DeleteMessage(ctx context.Context, req *pb.DeleteMessageRequest) (*empty.Empty, error) {
+	if s.closing() {
+		return nil, ErrShuttingDown
+	}
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	// muted sessions may still take back what they said
+	if err := s.checkBanned(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	msg, err := s.ownMessage(sess, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	s.Broadcast <- sess.attribute(&pb.Message{
+		Id:    sess.Id,
+		Room:  msg.Room,
+		Event: &pb.Message_Delete{Delete: &pb.MessageDelete{MessageId: msg.MessageId}},
+	})
+	return &empty.Empty{}, nil
+}
+
+// applyChange updates the stored message an edit or delete event is about.
+// It runs on the Run goroutine, for the events of every node.
+func (s *ChatServer) applyChange(msg *pb.Message) error {
+	var messageId string
+	switch event := msg.Event.(type) {
+	case *pb.Message_Edit:
+		messageId = event.Edit.MessageId
+	case *pb.Message_Delete:
+		messageId = event.Delete.MessageId
+	default:
+		return nil
+	}
+	stored, err := s.Store.Lookup(messageId)
+	if err == ErrMessageNotFound {
+		// gone from the history of this node already
+		return nil
+	} else if err != nil {
+		return err
+	}
+	changed := proto.Clone(stored.Msg).(*pb.Message)
+	switch event := msg.Event.(type) {
+	case *pb.Message_Edit:
+		setText(changed, event.Edit.Text)
+		changed.EditedAt = event.Edit.EditedAt
+	case *pb.Message_Delete:
+		changed.Text, changed.Content, changed.Deleted = "", nil, true
+	}
+	return s.Store.Update(changed)
+}
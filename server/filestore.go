@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileStore is a MessageStore appending one JSON record per line to a file.
+// Only an index of the records is kept in memory; messages are read back
+// from the file on Range. An update is appended as a record with the seq of
+// the message it replaces.
+type FileStore struct {
+	sync.RWMutex
+	fp     *os.File
+	size   int64
+	index  []fileRecordIndex
+	seq    uint64
+	closed bool
+}
+
+type fileRecord struct {
+	Seq     uint64          `json:"seq"`
+	Time    time.Time       `json:"time"`
+	Message json.RawMessage `json:"message"`
+}
+
+type fileRecordIndex struct {
+	seq       uint64
+	time      time.Time
+	room      string
+	messageId string
+	offset    int64
+	length    int
+}
+
+var (
+	marshaler   = jsonpb.Marshaler{}
+	unmarshaler = jsonpb.Unmarshaler{AllowUnknownFields: true}
+)
+
+func NewFileStore(path string) (*FileStore, error) {
+	fp, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileStore{fp: fp}
+	if err := f.load(); err != nil {
+		fp.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// load rebuilds the index from the records already in the file. A torn last
+// line, left by a crash during a write, is cut off.
+func (f *FileStore) load() error {
+	if _, err := f.fp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f.fp)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				if err := f.fp.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		} else if err != nil {
+			return err
+		}
+		idx, _, err := decodeRecord(line)
+		if err != nil {
+			return err
+		}
+		idx.offset = offset
+		offset += int64(len(line))
+		if idx.seq <= f.seq {
+			if i := f.position(idx.seq); i >= 0 {
+				f.index[i] = idx
+			}
+			continue
+		}
+		f.index = append(f.index, idx)
+		f.seq = idx.seq
+	}
+	f.size = offset
+	return nil
+}
+
+func encodeRecord(stored *StoredMessage) ([]byte, error) {
+	raw, err := marshaler.MarshalToString(stored.Msg)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(&fileRecord{
+		Seq:     stored.Seq,
+		Time:    stored.Time,
+		Message: json.RawMessage(raw),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func decodeRecord(line []byte) (fileRecordIndex, *pb.Message, error) {
+	var rec fileRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return fileRecordIndex{}, nil, err
+	}
+	msg := &pb.Message{}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(rec.Message), msg); err != nil {
+		return fileRecordIndex{}, nil, err
+	}
+	return fileRecordIndex{
+		seq:       rec.Seq,
+		time:      rec.Time,
+		room:      msg.Room,
+		messageId: msg.MessageId,
+		length:    len(line),
+	}, msg, nil
+}
+
+func (f *FileStore) Append(msg *pb.Message) (*StoredMessage, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return nil, ErrStoreClosed
+	}
+	stored := stamp(msg, f.seq+1, time.Now())
+	line, err := encodeRecord(stored)
+	if err == nil {
+		_, err = f.fp.Write(line)
+	}
+	if err != nil {
+		// the sequence number was not taken, do not hand it out
+		msg.Seq, msg.Timestamp = 0, nil
+		return nil, err
+	}
+	f.seq = stored.Seq
+	f.index = append(f.index, fileRecordIndex{
+		seq:       stored.Seq,
+		time:      stored.Time,
+		room:      msg.Room,
+		messageId: msg.MessageId,
+		offset:    f.size,
+		length:    len(line),
+	})
+	f.size += int64(len(line))
+	return stored, nil
+}
+
+func (f *FileStore) Lookup(messageId string) (*StoredMessage, error) {
+	f.RLock()
+	defer f.RUnlock()
+	if f.closed {
+		return nil, ErrStoreClosed
+	}
+	for _, idx := range f.index {
+		if messageId != "" && idx.messageId == messageId {
+			return f.read(idx)
+		}
+	}
+	return nil, ErrMessageNotFound
+}
+
+func (f *FileStore) Update(msg *pb.Message) error {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return ErrStoreClosed
+	}
+	i := -1
+	for j, idx := range f.index {
+		if msg.MessageId != "" && idx.messageId == msg.MessageId {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	idx := f.index[i]
+	line, err := encodeRecord(stamp(msg, idx.seq, idx.time))
+	if err != nil {
+		return err
+	}
+	if _, err := f.fp.Write(line); err != nil {
+		return err
+	}
+	idx.offset, idx.length = f.size, len(line)
+	f.index[i] = idx
+	f.size += int64(len(line))
+	return nil
+}
+
+// position returns where the record of seq is in the index, or -1. The index
+// is sorted by seq.
+func (f *FileStore) position(seq uint64) int {
+	i := sort.Search(len(f.index), func(i int) bool {
+		return f.index[i].seq >= seq
+	})
+	if i < len(f.index) && f.index[i].seq == seq {
+		return i
+	}
+	return -1
+}
+
+func (f *FileStore) Range(q Query) ([]*StoredMessage, error) {
+	f.RLock()
+	defer f.RUnlock()
+	if f.closed {
+		return nil, ErrStoreClosed
+	}
+	var matched []fileRecordIndex
+	for _, idx := range f.index {
+		if q.match(idx.seq, idx.time, idx.room) {
+			matched = append(matched, idx)
+		}
+	}
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[len(matched)-q.Limit:]
+	}
+	out := make([]*StoredMessage, 0, len(matched))
+	for _, idx := range matched {
+		stored, err := f.read(idx)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, stored)
+	}
+	return out, nil
+}
+
+func (f *FileStore) read(idx fileRecordIndex) (*StoredMessage, error) {
+	line := make([]byte, idx.length)
+	if _, err := f.fp.ReadAt(line, idx.offset); err != nil {
+		return nil, err
+	}
+	_, msg, err := decodeRecord(line)
+	if err != nil {
+		return nil, err
+	}
+	return stamp(msg, idx.seq, idx.time), nil
+}
+
+func (f *FileStore) Bounds() (uint64, uint64) {
+	f.RLock()
+	defer f.RUnlock()
+	if len(f.index) == 0 {
+		return 0, 0
+	}
+	return f.index[0].seq, f.seq
+}
+
+func (f *FileStore) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.fp.Close()
+}
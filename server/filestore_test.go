@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+)
+
+func openFileStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+	f, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func appendText(t *testing.T, f *FileStore, messageId, text string) {
+	t.Helper()
+	if _, err := f.Append(&pb.Message{Room: DefaultRoom, MessageId: messageId, Text: text}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+// checkTexts reads the lobby back from f and compares seqs and texts.
+func checkTexts(t *testing.T, f *FileStore, want ...string) {
+	t.Helper()
+	stored, err := f.Range(Query{Room: DefaultRoom})
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("Range returned %d messages, want %d", len(stored), len(want))
+	}
+	for i, m := range stored {
+		if m.Seq != uint64(i+1) || m.Msg.Seq != m.Seq {
+			t.Errorf("message %d has seq %d (%d in the message), want %d", i, m.Seq, m.Msg.Seq, i+1)
+		}
+		if m.Msg.Text != want[i] {
+			t.Errorf("message %d has text %q, want %q", i, m.Msg.Text, want[i])
+		}
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	f := openFileStore(t, path)
+	appendText(t, f, "m1", "one")
+	appendText(t, f, "m2", "two")
+	f.Close()
+
+	f = openFileStore(t, path)
+	if first, last := f.Bounds(); first != 1 || last != 2 {
+		t.Errorf("Bounds = %d, %d, want 1, 2", first, last)
+	}
+	appendText(t, f, "m3", "three")
+	checkTexts(t, f, "one", "two", "three")
+}
+
+func TestFileStoreUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	f := openFileStore(t, path)
+	appendText(t, f, "m1", "one")
+	appendText(t, f, "m2", "two")
+	if err := f.Update(&pb.Message{Room: DefaultRoom, MessageId: "m1", Text: "edited"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	checkTexts(t, f, "edited", "two")
+	f.Close()
+
+	// the update record comes last in the file but keeps the place of m1
+	f = openFileStore(t, path)
+	checkTexts(t, f, "edited", "two")
+	appendText(t, f, "m3", "three")
+	checkTexts(t, f, "edited", "two", "three")
+	stored, err := f.Lookup("m1")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if stored.Seq != 1 || stored.Msg.Text != "edited" {
+		t.Errorf("Lookup returned seq %d text %q, want 1 \"edited\"", stored.Seq, stored.Msg.Text)
+	}
+}
+
+func TestFileStoreTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	f := openFileStore(t, path)
+	appendText(t, f, "m1", "one")
+	appendText(t, f, "m2", "two")
+	f.Close()
+	size := fileSize(t, path)
+
+	// a crash in the middle of writing the third record
+	fp, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fp.WriteString(`{"seq":3,"time":"2020-01-01T00:00:00Z","mess`); err != nil {
+		t.Fatal(err)
+	}
+	fp.Close()
+
+	f = openFileStore(t, path)
+	if got := fileSize(t, path); got != size {
+		t.Errorf("file is %d bytes after reopening, want the torn line cut to %d", got, size)
+	}
+	checkTexts(t, f, "one", "two")
+	appendText(t, f, "m3", "three")
+	checkTexts(t, f, "one", "two", "three")
+	f.Close()
+
+	f = openFileStore(t, path)
+	checkTexts(t, f, "one", "two", "three")
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
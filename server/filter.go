@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MessageFilter looks at a message from a client before it is broadcast,
+// rewriting its text in place or refusing it with an InvalidArgument error.
+type MessageFilter interface {
+	Filter(msg *pb.Message) error
+}
+
+// FilterChain runs its filters in order, stopping at the first refusal.
+type FilterChain []MessageFilter
+
+func (c FilterChain) Filter(msg *pb.Message) error {
+	for _, f := range c {
+		if err := f.Filter(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	ErrMessageTooLong  = status.Error(codes.InvalidArgument, "[filter] message is too long")
+	ErrLinkNotAllowed  = status.Error(codes.InvalidArgument, "[filter] links to that site are not allowed")
+	ErrMalformedFilter = errors.New("[filter] malformed filter config")
+)
+
+// texts returns the text fields of msg a client wrote.
+func texts(msg *pb.Message) []*string {
+	fields := []*string{&msg.Text}
+	switch c := msg.Content.(type) {
+	case *pb.Message_Markdown:
+		fields = append(fields, &c.Markdown.Source)
+	case *pb.Message_Code:
+		fields = append(fields, &c.Code.Source)
+	case *pb.Message_Attachment:
+		fields = append(fields, &c.Attachment.Name)
+	}
+	return fields
+}
+
+// MaxLengthFilter refuses messages with a text of more than Max characters.
+type MaxLengthFilter struct {
+	Max int
+}
+
+func (f MaxLengthFilter) Filter(msg *pb.Message) error {
+	for _, text := range texts(msg) {
+		if utf8.RuneCountInString(*text) > f.Max {
+			return ErrMessageTooLong
+		}
+	}
+	return nil
+}
+
+// RedactFilter replaces what Pattern matches, such as API keys pasted by
+// mistake, with Replacement.
+type RedactFilter struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (f RedactFilter) Filter(msg *pb.Message) error {
+	for _, text := range texts(msg) {
+		*text = f.Pattern.ReplaceAllLiteralString(*text, f.Replacement)
+	}
+	return nil
+}
+
+// ProfanityFilter masks the words of its list, whatever their case, with
+// asterisks.
+type ProfanityFilter struct {
+	words *regexp.Regexp
+}
+
+func NewProfanityFilter(words []string) *ProfanityFilter {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	return &ProfanityFilter{words: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+func (f *ProfanityFilter) Filter(msg *pb.Message) error {
+	for _, text := range texts(msg) {
+		*text = f.words.ReplaceAllStringFunc(*text, func(word string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(word))
+		})
+	}
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)[^\s<>"'()\[\]]+`)
+
+// LinkFilter refuses messages linking to a host outside of Allow, or to a
+// subdomain of one. With an empty Allow it refuses every link.
+type LinkFilter struct {
+	Allow []string
+}
+
+func (f LinkFilter) Filter(msg *pb.Message) error {
+	var links []string
+	for _, text := range texts(msg) {
+		links = append(links, linkPattern.FindAllString(*text, -1)...)
+	}
+	if a := msg.GetAttachment(); a != nil && a.Url != "" {
+		links = append(links, a.Url)
+	}
+	for _, link := range links {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		u, err := url.Parse(link)
+		if err != nil || !f.allowed(strings.ToLower(u.Hostname())) {
+			return ErrLinkNotAllowed
+		}
+	}
+	return nil
+}
+
+func (f LinkFilter) allowed(host string) bool {
+	for _, allow := range f.Allow {
+		allow = strings.ToLower(allow)
+		if host == allow || strings.HasSuffix(host, "."+allow) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterConfig is the file given to -filters, for example:
+//
+//	{
+//	  "max_length": 2000,
+//	  "profanity": ["darn", "heck"],
+//	  "links": {"allow": ["golang.org", "github.com"]},
+//	  "redact": [{"pattern": "AKIA[0-9A-Z]{16}", "replacement": "[aws key]"}]
+//	}
+//
+// Every section is optional. Leaving out links allows every link.
+type FilterConfig struct {
+	MaxLength int      `json:"max_length"`
+	Profanity []string `json:"profanity"`
+	Links     *struct {
+		Allow []string `json:"allow"`
+	} `json:"links"`
+	Redact []struct {
+		Pattern     string `json:"pattern"`
+		Replacement string `json:"replacement"`
+	} `json:"redact"`
+}
+
+// LoadFilters builds the chain described by the FilterConfig in path. The
+// length is checked on what the client sent, links once secrets are redacted.
+func LoadFilters(path string) (FilterChain, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg FilterConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	var chain FilterChain
+	if cfg.MaxLength < 0 {
+		return nil, ErrMalformedFilter
+	} else if cfg.MaxLength > 0 {
+		chain = append(chain, MaxLengthFilter{Max: cfg.MaxLength})
+	}
+	for _, r := range cfg.Redact {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, RedactFilter{Pattern: pattern, Replacement: r.Replacement})
+	}
+	if len(cfg.Profanity) > 0 {
+		chain = append(chain, NewProfanityFilter(cfg.Profanity))
+	}
+	if cfg.Links != nil {
+		chain = append(chain, LinkFilter{Allow: cfg.Links.Allow})
+	}
+	return chain, nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthServiceName is the service the health checks ask about, next to the
+// empty name standing for the whole server.
+const HealthServiceName = "pb.chatService"
+
+// UseHealth registers the grpc.health.v1 service on server, reporting the
+// chat service as serving until Shutdown starts draining it.
+func (s *ChatServer) UseHealth(server *grpc.Server) {
+	h := health.NewServer()
+	h.SetServingStatus(HealthServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, h)
+	s.m.Lock()
+	s.health = h
+	s.m.Unlock()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys used to hand the session identity to the client on Subscribe
+// and to read it back on every other call. Through the gateway they travel as
+// Grpc-Metadata-X-Session-Id / Grpc-Metadata-X-Session-Token headers.
+const (
+	SessionIdKey    = "x-session-id"
+	SessionTokenKey = "x-session-token"
+)
+
+var (
+	ErrMissingToken   = status.Error(codes.Unauthenticated, "[identity] missing session token")
+	ErrInvalidToken   = status.Error(codes.Unauthenticated, "[identity] unknown session token")
+	ErrSenderMismatch = status.Error(codes.PermissionDenied, "[identity] sender id does not match session")
+	ErrReplaced       = status.Error(codes.Aborted, "[identity] replaced by a new session with the same identity")
+)
+
+func generateToken() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// SessionFromContext resolves the calling session from the token carried in
+// the incoming gRPC metadata. With CertIdentity, a call without a token is
+// resolved by its client certificate instead, and a token must belong to the
+// session of the certificate. The frames of a chat stream carry the session
+// of the stream in ctx instead.
+func (s *ChatServer) SessionFromContext(ctx context.Context) (*Session, error) {
+	if sess, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		if cur, err := s.SessionByID(sess.Id); err != nil || cur != sess {
+			return nil, ErrInvalidToken
+		}
+		return sess, nil
+	}
+	certId, hasCert := s.identityFromCert(ctx)
+	token := tokenFromContext(ctx)
+	if token == "" {
+		if hasCert {
+			sess, err := s.SessionByID(certId)
+			if err != nil {
+				return nil, ErrInvalidToken
+			}
+			if user, ok := UserFromContext(ctx); ok && user != sess.User {
+				return nil, ErrUserMismatch
+			}
+			return sess, nil
+		}
+		return nil, ErrMissingToken
+	}
+	s.m.RLock()
+	sess, ok := s.tokens[token]
+	s.m.RUnlock()
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if hasCert && sess.Id != certId {
+		return nil, ErrSenderMismatch
+	}
+	if user, ok := UserFromContext(ctx); ok && user != sess.User {
+		return nil, ErrUserMismatch
+	}
+	return sess, nil
+}
+
+func tokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	tokens := md.Get(SessionTokenKey)
+	if len(tokens) == 0 {
+		return ""
+	}
+	return tokens[0]
+}
+
+// authorize resolves the caller and checks it against the id it claims to be.
+// An empty claimed id is accepted and means "whoever holds the token".
+func (s *ChatServer) authorize(ctx context.Context, id string) (*Session, error) {
+	sess, err := s.SessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id != "" && id != sess.Id {
+		return nil, ErrSenderMismatch
+	}
+	return sess, nil
+}
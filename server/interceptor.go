@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"path"
+	"strings"
+	"time"
+)
+
+// grpc.Server takes a single interceptor of each kind, so the server's own
+// interceptors are chained here, the first one being the outermost.
+
+func chainUnary(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, h := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, h)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+func chainStream(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, h := interceptors[i], next
+			next = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, h)
+			}
+		}
+		return next(srv, ss)
+	}
+}
+
+// methodName returns the lower-cased RPC name of a full method name such as
+// "/pb.chatService/Send".
+func methodName(fullMethod string) string {
+	return strings.ToLower(path.Base(fullMethod))
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// LoggingUnaryInterceptor logs every call with its method, peer address,
+// status code and duration at debug level.
+func (s *ChatServer) LoggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// LoggingStreamInterceptor logs every stream like LoggingUnaryInterceptor,
+// once it ended.
+func (s *ChatServer) LoggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	s.logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func (s *ChatServer) logCall(ctx context.Context, fullMethod string, start time.Time, err error) {
+	s.Logger.Debug("call", "event", "rpc",
+		"method", methodName(fullMethod),
+		"peer", peerAddr(ctx),
+		"code", status.Code(err).String(),
+		"duration", time.Since(start))
+}
+
+// public reports whether fullMethod may be called without logging in: Login
+// itself, the health checks of orchestrators and the broker stream between
+// nodes, which checks the peer token itself.
+func public(fullMethod string) bool {
+	return methodName(fullMethod) == "login" ||
+		strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(fullMethod, "/pb.brokerService/")
+}
+
+// AuthUnaryInterceptor rejects calls without a valid bearer token, except
+// the public ones.
+func (s *ChatServer) AuthUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if public(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor rejects streams without a valid bearer token, except
+// the public ones.
+func (s *ChatServer) AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if public(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/peer"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+var ErrUnknownLogFormat = errors.New("[log] unknown log format, want text or json")
+
+// NewLogger writes records of level and above to w as text or json lines.
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, ErrUnknownLogFormat
+}
+
+// grpcLogger passes the records of grpc on to a slog logger, with its info
+// records at debug level since grpc logs every connection at info.
+type grpcLogger struct {
+	logger *slog.Logger
+}
+
+// NewGRPCLogger returns a grpclog.LoggerV2 writing through logger.
+func NewGRPCLogger(logger *slog.Logger) grpclog.LoggerV2 {
+	return grpcLogger{logger.With("component", "grpc")}
+}
+
+func (g grpcLogger) Info(args ...interface{}) {
+	g.logger.Debug(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Infoln(args ...interface{}) {
+	g.logger.Debug(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Warning(args ...interface{}) {
+	g.logger.Warn(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Warningln(args ...interface{}) {
+	g.logger.Warn(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Error(args ...interface{}) {
+	g.logger.Error(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Errorln(args ...interface{}) {
+	g.logger.Error(fmt.Sprint(args...))
+}
+
+func (g grpcLogger) Infof(format string, args ...interface{}) {
+	g.logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (g grpcLogger) Warningf(format string, args ...interface{}) {
+	g.logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (g grpcLogger) Errorf(format string, args ...interface{}) {
+	g.logger.Error(fmt.Sprintf(format, args...))
+}
+
+func (g grpcLogger) Fatal(args ...interface{}) {
+	g.logger.Error(fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (g grpcLogger) Fatalln(args ...interface{}) {
+	g.Fatal(args...)
+}
+
+func (g grpcLogger) Fatalf(format string, args ...interface{}) {
+	g.Fatal(fmt.Sprintf(format, args...))
+}
+
+// V reports whether grpc should log at verbosity l; like its own logger,
+// only the records without verbosity are.
+func (g grpcLogger) V(l int) bool {
+	return l <= 0
+}
+
+// discardLogger is the logger of servers nobody set one for.
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// logger returns the server logger with the id and peer address of sess
+// attached, or the server logger itself when sess is nil.
+func (s *ChatServer) logger(sess *Session) *slog.Logger {
+	if sess == nil {
+		return s.Logger
+	}
+	return s.Logger.With("session", sess.Id, "peer", sess.peer)
+}
+
+// reportError logs err and counts it in the metrics. event names what the
+// server was doing, as in the other log records.
+func (s *ChatServer) reportError(sess *Session, event string, err error) {
+	atomic.AddUint64(&s.metrics.errors, 1)
+	s.logger(sess).Error(err.Error(), "event", event)
+}
+
+// peerAddr returns the address of the client the call came from.
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
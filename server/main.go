@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/grpclog"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+)
+
+func main() {
+	port := flag.Int("port", 40040, "port")
+	maxRooms := flag.Int("max-rooms", DefaultMaxRooms, "number of rooms clients may create up to, 0 for no limit")
+	historySize := flag.Int("history", 1000, "number of messages kept in memory for history")
+	historyFile := flag.String("history-file", "", "append message history to this file instead of keeping it in memory")
+	slowConsumer := flag.String("slow-consumer", "drop-newest", "what to do when a client falls behind: drop-newest, drop-oldest, block or disconnect")
+	slowConsumerTimeout := flag.Duration("slow-consumer-timeout", 100*time.Millisecond, "how long the block policy waits for a slow client")
+	shutdownMessage := flag.String("shutdown-message", "server is going down", "message broadcast to every room on shutdown, empty for none")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "how long to wait for clients to receive queued messages on shutdown")
+	certFile := flag.String("cert", "", "TLS certificate file, serves plaintext when empty")
+	keyFile := flag.String("key", "", "TLS private key file")
+	caFile := flag.String("ca", "", "CA file to verify client certificates with, enables mutual TLS")
+	certIdentity := flag.Bool("cert-identity", false, "use the common name of the client certificate as session id")
+	gatewayIdentities := flag.String("gateway-identities", "", "comma separated common names of the client certificates of gateways, which -cert-identity leaves out")
+	authUsers := flag.String("auth-users", "", "file of name:sha256(password) lines, requires login for every call when set")
+	authSecretFile := flag.String("auth-secret-file", "", "file holding the secret access tokens are signed with, random when empty")
+	authTTL := flag.Duration("auth-ttl", 24*time.Hour, "lifetime of access tokens")
+	idleTimeout := flag.Duration("idle-timeout", 5*time.Minute, "inactivity after which a session is shown as idle, 0 to disable")
+	typingTimeout := flag.Duration("typing-timeout", 5*time.Second, "how long a typing indicator lasts unless the client refreshes it")
+	attachmentsDir := flag.String("attachments-dir", "", "directory to store uploaded attachments in, uploads are refused when empty")
+	maxAttachmentSize := flag.Int64("max-attachment-size", DefaultMaxAttachmentSize, "largest attachment accepted, in bytes")
+	rate := flag.Float64("rate", DefaultSessionRate, "calls per second a session may make on average that others see, such as sends, edits and typing, 0 for no limit")
+	burst := flag.Int("burst", DefaultSessionBurst, "calls a session may make at once")
+	peerRate := flag.Float64("ip-rate", 0, "calls per second the sessions of one address may make on average, 0 for no limit; behind the gateway only with -trust-forwarded-for")
+	peerBurst := flag.Int("ip-burst", DefaultPeerBurst, "calls the sessions of one address may make at once")
+	maxStreams := flag.Int("max-streams-per-ip", 0, "streams one address may hold open, 0 for no limit; behind the gateway only with -trust-forwarded-for")
+	trustForwardedFor := flag.Bool("trust-forwarded-for", false, "limit by the client address the gateway puts in X-Forwarded-For, only safe when clients cannot reach the server directly")
+	rolesFile := flag.String("roles", "", "file of name:role lines giving user names or certificate identities the moderator or admin role")
+	banFile := flag.String("ban-file", "", "file the ban list is kept in, bans are forgotten on restart when empty")
+	filters := flag.String("filters", "", "JSON file configuring the filters messages pass before broadcast, see FilterConfig")
+	peers := flag.String("peers", "", "comma separated addresses of the other chat server nodes, which have to share -peer-secret-file")
+	peerSecretFile := flag.String("peer-secret-file", "", "file holding the secret the nodes of -peers recognize each other by, required with -peers")
+	metricsAddr := flag.String("metrics-addr", "", "address to serve Prometheus metrics on at /metrics, disabled when empty")
+	logFormat := flag.String("log-format", "text", "log output format: text or json")
+	logLevel := flag.String("log-level", "info", "lowest level logged: debug, info, warn or error")
+	flag.Parse()
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatalf("[main] %v", err)
+	}
+	logger, err := NewLogger(os.Stderr, *logFormat, level)
+	if err != nil {
+		log.Fatalf("[main] %v", err)
+	}
+	// the log package calls below write through logger too, and so does grpc
+	slog.SetDefault(logger)
+	grpclog.SetLoggerV2(NewGRPCLogger(logger))
+	policy, err := ParseSlowConsumerPolicy(*slowConsumer)
+	if err != nil {
+		log.Fatalf("[main] %v", err)
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", *port))
+	if err != nil {
+		log.Fatalf("[main] failed to listen: %v", err)
+	}
+
+	var opt []grpc.ServerOption
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	if *certFile != "" {
+		cfg, err := serverTLSConfig(*certFile, *keyFile, *caFile)
+		if err != nil {
+			log.Fatalf("[main] failed to load certificates: %v", err)
+		}
+		opt = append(opt, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	gs := NewServer()
+	gs.Logger = logger
+	unary = append(unary, gs.LoggingUnaryInterceptor)
+	stream = append(stream, gs.LoggingStreamInterceptor)
+	if *authUsers != "" {
+		authenticator, err := NewFileAuthenticator(*authUsers)
+		if err != nil {
+			log.Fatalf("[main] failed to load users: %v", err)
+		}
+		var secret []byte
+		if *authSecretFile != "" {
+			if secret, err = ioutil.ReadFile(*authSecretFile); err != nil {
+				log.Fatalf("[main] failed to read token secret: %v", err)
+			}
+		}
+		gs.Authenticator = authenticator
+		gs.Tokens = NewTokenIssuer(bytes.TrimSpace(secret), *authTTL)
+		unary = append(unary, gs.AuthUnaryInterceptor)
+		stream = append(stream, gs.AuthStreamInterceptor)
+	}
+	opt = append(opt,
+		grpc.UnaryInterceptor(chainUnary(unary...)),
+		grpc.StreamInterceptor(chainStream(stream...)),
+	)
+	server := grpc.NewServer(opt...)
+	gs.SlowConsumer = policy
+	gs.SlowConsumerTimeout = *slowConsumerTimeout
+	gs.ShutdownMessage = *shutdownMessage
+	gs.CertIdentity = *certIdentity
+	if *gatewayIdentities != "" {
+		gs.GatewayIdentities = make(map[string]bool)
+		for _, name := range strings.Split(*gatewayIdentities, ",") {
+			gs.GatewayIdentities[strings.TrimSpace(name)] = true
+		}
+	}
+	gs.IdleTimeout = *idleTimeout
+	gs.TypingTimeout = *typingTimeout
+	gs.MaxAttachmentSize = *maxAttachmentSize
+	gs.MaxRooms = *maxRooms
+	gs.SessionLimit, gs.PeerLimit = nil, nil
+	if *rate > 0 {
+		gs.SessionLimit = NewRateLimiter(*rate, *burst)
+	}
+	if *peerRate > 0 {
+		gs.PeerLimit = NewRateLimiter(*peerRate, *peerBurst)
+	}
+	gs.MaxStreamsPerIP = *maxStreams
+	gs.TrustForwardedFor = *trustForwardedFor
+	if (*peerRate > 0 || *maxStreams > 0) && !*trustForwardedFor {
+		logger.Warn("limits per address count all clients of a gateway as one, unless -trust-forwarded-for", "event", "start")
+	}
+	if *rolesFile != "" {
+		if gs.Roles, err = LoadRoles(*rolesFile); err != nil {
+			log.Fatalf("[main] failed to load roles: %v", err)
+		}
+	}
+	if gs.Bans, err = LoadBanList(*banFile); err != nil {
+		log.Fatalf("[main] failed to load ban list: %v", err)
+	}
+	if *filters != "" {
+		if gs.Filters, err = LoadFilters(*filters); err != nil {
+			log.Fatalf("[main] failed to load filters: %v", err)
+		}
+	}
+	if *attachmentsDir != "" {
+		blobs, err := NewFileBlobStore(*attachmentsDir)
+		if err != nil {
+			log.Fatalf("[main] failed to open attachments directory: %v", err)
+		}
+		gs.Blobs = blobs
+	}
+	if *historyFile != "" {
+		store, err := NewFileStore(*historyFile)
+		if err != nil {
+			log.Fatalf("[main] failed to open history file: %v", err)
+		}
+		gs.Store = store
+	} else {
+		gs.Store = NewMemoryStore(*historySize)
+	}
+	pb.RegisterChatServiceServer(server, gs)
+	gs.UseHealth(server)
+	if *peers != "" {
+		dial := grpc.WithInsecure()
+		if *certFile != "" {
+			cfg, err := peerTLSConfig(*certFile, *keyFile, *caFile)
+			if err != nil {
+				log.Fatalf("[main] failed to load certificates: %v", err)
+			}
+			dial = grpc.WithTransportCredentials(credentials.NewTLS(cfg))
+		}
+		var secret []byte
+		if *peerSecretFile != "" {
+			if secret, err = ioutil.ReadFile(*peerSecretFile); err != nil {
+				log.Fatalf("[main] failed to read peer secret: %v", err)
+			}
+		}
+		broker, err := NewPeerBroker(strings.Split(*peers, ","), bytes.TrimSpace(secret), dial)
+		if err != nil {
+			log.Fatalf("[main] failed to link peers: %v", err)
+		}
+		broker.Logger = logger
+		pb.RegisterBrokerServiceServer(server, broker)
+		gs.UseBroker(broker)
+		broker.Start()
+	}
+	logger.Info("server is running", "event", "start", "port", *port)
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", gs.MetricsHandler())
+		logger.Info("serving metrics", "event", "start", "addr", *metricsAddr)
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Fatalf("[main] metrics: %v", err)
+			}
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+	logger.Info("shutting down", "event", "shutdown", "signal", (<-sig).String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := gs.Shutdown(ctx); err != nil {
+		logger.Warn("chat server shutdown failed", "event", "shutdown", "err", err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Warn("graceful stop timed out, closing remaining connections", "event", "shutdown")
+		server.Stop()
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fanoutBuckets are the upper bounds in seconds of the fan-out latency
+// histogram.
+var fanoutBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// Metrics counts what the server does, for MetricsHandler to expose.
+type Metrics struct {
+	sent    uint64
+	dropped uint64
+	errors  uint64
+	limited uint64
+	// peerDropped counts the messages a peer missed for being too far
+	// behind.
+	peerDropped uint64
+
+	m      sync.Mutex
+	counts []uint64 // per bucket of fanoutBuckets, the last one being +Inf
+	sum    float64
+	count  uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{counts: make([]uint64, len(fanoutBuckets)+1)}
+}
+
+func (m *Metrics) observeFanout(d time.Duration) {
+	seconds := d.Seconds()
+	i := 0
+	for i < len(fanoutBuckets) && seconds > fanoutBuckets[i] {
+		i++
+	}
+	m.m.Lock()
+	m.counts[i]++
+	m.sum += seconds
+	m.count++
+	m.m.Unlock()
+}
+
+// MetricsHandler serves the metrics of s in the Prometheus text exposition
+// format.
+func (s *ChatServer) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		out := bufio.NewWriter(w)
+		defer out.Flush()
+
+		s.m.RLock()
+		sessions := len(s.Gophers)
+		s.m.RUnlock()
+		metric(out, "chat_sessions", "gauge", "Sessions connected to this node.")
+		fmt.Fprintf(out, "chat_sessions %d\n", sessions)
+
+		metric(out, "chat_channel_depth", "gauge", "Items waiting in the channels read by Run.")
+		fmt.Fprintf(out, "chat_channel_depth{channel=\"broadcast\"} %d\n", len(s.Broadcast))
+		fmt.Fprintf(out, "chat_channel_depth{channel=\"connect\"} %d\n", len(s.Connect))
+		fmt.Fprintf(out, "chat_channel_depth{channel=\"disconnect\"} %d\n", len(s.Disconnect))
+
+		metric(out, "chat_messages_sent_total", "counter", "Messages fanned out by Run.")
+		fmt.Fprintf(out, "chat_messages_sent_total %d\n", atomic.LoadUint64(&s.metrics.sent))
+		metric(out, "chat_messages_dropped_total", "counter", "Messages dropped by the slow consumer policy.")
+		fmt.Fprintf(out, "chat_messages_dropped_total %d\n", atomic.LoadUint64(&s.metrics.dropped))
+		metric(out, "chat_messages_limited_total", "counter", "Messages refused by the rate limits.")
+		fmt.Fprintf(out, "chat_messages_limited_total %d\n", atomic.LoadUint64(&s.metrics.limited))
+		metric(out, "chat_broker_dropped_total", "counter", "Messages not forwarded to a peer that was too far behind.")
+		fmt.Fprintf(out, "chat_broker_dropped_total %d\n", atomic.LoadUint64(&s.metrics.peerDropped))
+		metric(out, "chat_errors_total", "counter", "Errors logged by the server.")
+		fmt.Fprintf(out, "chat_errors_total %d\n", atomic.LoadUint64(&s.metrics.errors))
+
+		m := s.metrics
+		m.m.Lock()
+		counts := append([]uint64(nil), m.counts...)
+		sum, count := m.sum, m.count
+		m.m.Unlock()
+		metric(out, "chat_fanout_duration_seconds", "histogram", "Time Run takes to store a message and queue it for its audience.")
+		var cumulative uint64
+		for i, le := range fanoutBuckets {
+			cumulative += counts[i]
+			fmt.Fprintf(out, "chat_fanout_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(out, "chat_fanout_duration_seconds_bucket{le=\"+Inf\"} %d\n", count)
+		fmt.Fprintf(out, "chat_fanout_duration_seconds_sum %s\n", strconv.FormatFloat(sum, 'g', -1, 64))
+		fmt.Fprintf(out, "chat_fanout_duration_seconds_count %d\n", count)
+	})
+}
+
+func metric(out *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
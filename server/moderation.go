@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotModerator   = status.Error(codes.PermissionDenied, "[moderation] only moderators and admins may do that")
+	ErrOutranked      = status.Error(codes.PermissionDenied, "[moderation] the target does not have a lower role")
+	ErrNoIdentity     = status.Error(codes.FailedPrecondition, "[moderation] the target has no lasting identity, ban its address instead")
+	ErrSharedAddress  = status.Error(codes.FailedPrecondition, "[moderation] the target came through a gateway whose address it shares with others")
+	ErrInvalidBan     = status.Error(codes.InvalidArgument, "[moderation] duration must not be negative")
+	ErrBanNotFound    = status.Error(codes.NotFound, "[moderation] no ban on that identity")
+	ErrKicked         = status.Error(codes.PermissionDenied, "[moderation] kicked by a moderator")
+	ErrBanned         = status.Error(codes.PermissionDenied, "[moderation] banned from this server")
+	ErrMuted          = status.Error(codes.PermissionDenied, "[moderation] muted by a moderator")
+	ErrMalformedRoles = errors.New("[moderation] malformed roles file")
+)
+
+// eviction asks Run to deliver events and then disconnect sess with err.
+type eviction struct {
+	sess   *Session
+	err    error
+	events []*pb.Message
+}
+
+// LoadRoles reads "name:role" lines, name being a user name or certificate
+// identity and role one of member, moderator or admin. Empty lines and lines
+// starting with # are skipped.
+func LoadRoles(path string) (map[string]pb.Role, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	roles := make(map[string]pb.Role)
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, ":")
+		if i <= 0 {
+			return nil, ErrMalformedRoles
+		}
+		role, ok := pb.Role_value[strings.ToUpper(line[i+1:])]
+		if !ok {
+			return nil, ErrMalformedRoles
+		}
+		roles[line[:i]] = pb.Role(role)
+	}
+	return roles, scanner.Err()
+}
+
+// identity is what roles, bans and mutes of sess are keyed by: its user name,
+// else its id, which lasts across sessions only when it is the identity of
+// its client certificate. lasting tells which.
+func (s *ChatServer) identity(sess *Session) (identity string, lasting bool) {
+	if sess.User != "" {
+		return sess.User, true
+	}
+	return sess.Id, sess.certified
+}
+
+func (s *ChatServer) roleOf(sess *Session) pb.Role {
+	if identity, lasting := s.identity(sess); lasting {
+		return s.Roles[identity]
+	}
+	return pb.Role_MEMBER
+}
+
+// checkBanned refuses the identity and address of a call that are banned.
+func (s *ChatServer) checkBanned(ctx context.Context, sess *Session) error {
+	identity, lasting := s.identity(sess)
+	if !lasting {
+		identity = ""
+	}
+	if _, banned := s.Bans.Banned(identity, s.clientIP(ctx)); banned {
+		return ErrBanned
+	}
+	return nil
+}
+
+// checkMuted refuses to let a muted session speak.
+func (s *ChatServer) checkMuted(sess *Session) error {
+	identity, _ := s.identity(sess)
+	s.m.RLock()
+	until, ok := s.mutes[identity]
+	s.m.RUnlock()
+	if ok && time.Now().Before(until) {
+		return ErrMuted
+	}
+	return nil
+}
+
+// checkVoice refuses a banned or muted session the calls that broadcast what
+// it has to say.
+func (s *ChatServer) checkVoice(ctx context.Context, sess *Session) error {
+	if err := s.checkBanned(ctx, sess); err != nil {
+		return err
+	}
+	return s.checkMuted(sess)
+}
+
+// moderate resolves the moderator calling and the session it acts on.
+func (s *ChatServer) moderate(ctx context.Context, req *pb.ModerationRequest) (*Session, *Session, error) {
+	if s.closing() {
+		return nil, nil, ErrShuttingDown
+	}
+	mod, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mod.role < pb.Role_MODERATOR {
+		return nil, nil, ErrNotModerator
+	}
+	target, err := s.SessionByID(req.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target.role >= mod.role {
+		return nil, nil, ErrOutranked
+	}
+	return mod, target, nil
+}
+
+// moderationEvents builds one event per room target has joined.
+func (s *ChatServer) moderationEvents(mod, target *Session, event *pb.Moderation) []*pb.Message {
+	s.m.RLock()
+	rooms := s.roomsOf(target)
+	s.m.RUnlock()
+	events := make([]*pb.Message, 0, len(rooms))
+	for _, room := range rooms {
+		events = append(events, mod.attribute(&pb.Message{
+			Id:    mod.Id,
+			Room:  room,
+			Event: &pb.Message_Moderation{Moderation: event},
+		}))
+	}
+	return events
+}
+
+// Kick disconnects the target, which may come back right away.
+func (s *ChatServer) Kick(ctx context.Context, req *pb.ModerationRequest) (*empty.Empty, error) {
+	mod, target, err := s.moderate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger(mod).Info("kick", "event", "moderation", "target", target.Id, "reason", req.Reason)
+	s.evict <- eviction{
+		sess: target,
+		err:  ErrKicked,
+		events: s.moderationEvents(mod, target, &pb.Moderation{
+			Action: pb.Moderation_KICK,
+			Target: target.Id,
+			Reason: req.Reason,
+		}),
+	}
+	return &empty.Empty{}, nil
+}
+
+// Ban disconnects the target and keeps its identity, and with by_address its
+// address, from coming back.
+func (s *ChatServer) Ban(ctx context.Context, req *pb.ModerationRequest) (*empty.Empty, error) {
+	mod, target, err := s.moderate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	until, err := s.until(req)
+	if err != nil {
+		return nil, err
+	}
+	// moderators always have a lasting identity, unlike their session id
+	by, _ := s.identity(mod)
+	ban := Ban{Reason: req.Reason, By: by}
+	if identity, lasting := s.identity(target); lasting {
+		ban.Identity = identity
+	} else if !req.ByAddress {
+		return nil, ErrNoIdentity
+	}
+	if req.ByAddress {
+		if target.sharedAddress {
+			return nil, ErrSharedAddress
+		}
+		ban.Address = target.address
+	}
+	event := &pb.Moderation{Action: pb.Moderation_BAN, Target: target.Id, Reason: req.Reason}
+	if !until.IsZero() {
+		ban.Until = &until
+		event.Until, _ = ptypes.TimestampProto(until)
+	}
+	if err := s.Bans.Add(ban); err != nil {
+		return nil, err
+	}
+	s.logger(mod).Info("ban", "event", "moderation", "target", target.Id, "identity", ban.Identity, "address", ban.Address, "reason", req.Reason)
+	s.evict <- eviction{
+		sess:   target,
+		err:    ErrBanned,
+		events: s.moderationEvents(mod, target, event),
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *ChatServer) Unban(ctx context.Context, req *pb.UnbanRequest) (*empty.Empty, error) {
+	mod, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if mod.role < pb.Role_MODERATOR {
+		return nil, ErrNotModerator
+	}
+	removed, err := s.Bans.Remove(req.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrBanNotFound
+	}
+	s.logger(mod).Info("unban", "event", "moderation", "identity", req.Identity)
+	return &empty.Empty{}, nil
+}
+
+// Mute keeps the target from sending, editing, typing, renaming itself and
+// uploading for the duration of req, lifting the mute when there is none.
+func (s *ChatServer) Mute(ctx context.Context, req *pb.ModerationRequest) (*empty.Empty, error) {
+	mod, target, err := s.moderate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	until, err := s.until(req)
+	if err != nil {
+		return nil, err
+	}
+	identity, _ := s.identity(target)
+	event := &pb.Moderation{Action: pb.Moderation_MUTE, Target: target.Id, Reason: req.Reason}
+	s.m.Lock()
+	if until.IsZero() {
+		delete(s.mutes, identity)
+		event.Action = pb.Moderation_UNMUTE
+	} else {
+		s.mutes[identity] = until
+		event.Until, _ = ptypes.TimestampProto(until)
+	}
+	s.m.Unlock()
+	s.logger(mod).Info("mute", "event", "moderation", "target", target.Id, "until", until, "reason", req.Reason)
+	for _, msg := range s.moderationEvents(mod, target, event) {
+		s.Broadcast <- msg
+	}
+	return &empty.Empty{}, nil
+}
+
+// until turns the duration of req into the time it ends, zero without one.
+func (s *ChatServer) until(req *pb.ModerationRequest) (time.Time, error) {
+	if req.Duration == nil {
+		return time.Time{}, nil
+	}
+	d, err := ptypes.Duration(req.Duration)
+	if err != nil || d < 0 {
+		return time.Time{}, ErrInvalidBan
+	}
+	if d == 0 {
+		return time.Time{}, nil
+	}
+	return time.Now().Add(d), nil
+}
+
+// pruneMutes forgets the mutes that ran out. It runs on the Run goroutine.
+func (s *ChatServer) pruneMutes() {
+	now := time.Now()
+	s.m.Lock()
+	defer s.m.Unlock()
+	for identity, until := range s.mutes {
+		if now.After(until) {
+			delete(s.mutes, identity)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startModerated serves a server known by certificates, where "mod" is a
+// moderator and "gateway" a gateway, and returns it with a client for each.
+func startModerated(t *testing.T, trustForwardedFor bool) (gs *ChatServer, mod, member, gateway pb.ChatServiceClient) {
+	t.Helper()
+	p := newTestPKI(t)
+	addr := startServer(t, func(s *ChatServer) {
+		gs = s
+		s.CertIdentity = true
+		s.GatewayIdentities = map[string]bool{"gateway": true}
+		s.Roles = map[string]pb.Role{"mod": pb.Role_MODERATOR}
+		s.TrustForwardedFor = trustForwardedFor
+	}, serverCreds(t, p, p.path("ca")))
+	clients := make([]pb.ChatServiceClient, 3)
+	for i, cn := range []string{"mod", "gopher", "gateway"} {
+		client, err := dial(t, addr, p.clientCreds(p.clientCert(t, cn)))
+		if err != nil {
+			t.Fatalf("call as %s failed: %v", cn, err)
+		}
+		clients[i] = client
+	}
+	return gs, clients[0], clients[1], clients[2]
+}
+
+func ban(mod *testSession, client pb.ChatServiceClient, target string) error {
+	ctx, cancel := context.WithTimeout(mod.ctx, 2*time.Second)
+	defer cancel()
+	_, err := client.Ban(ctx, &pb.ModerationRequest{Id: mod.id, Target: target, ByAddress: true})
+	return err
+}
+
+func TestBanByAddress(t *testing.T) {
+	gs, mod, member, _ := startModerated(t, false)
+	moderator := open(t, mod, &pb.SubscribeRequest{})
+	gopher := open(t, member, &pb.SubscribeRequest{})
+	if err := ban(moderator, mod, gopher.id); err != nil {
+		t.Fatalf("Ban: %v", err)
+	}
+	b, banned := gs.Bans.Banned("gopher", "127.0.0.1")
+	if !banned {
+		t.Fatal("gopher is not banned")
+	}
+	if b.Address != "127.0.0.1" || b.By != "mod" {
+		t.Errorf("ban has address %q by %q, want 127.0.0.1 by mod", b.Address, b.By)
+	}
+}
+
+func TestBanByAddressThroughGateway(t *testing.T) {
+	_, mod, _, gateway := startModerated(t, false)
+	moderator := open(t, mod, &pb.SubscribeRequest{})
+	client := open(t, gateway, &pb.SubscribeRequest{})
+	if err := ban(moderator, mod, client.id); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("banning the address of a gateway client returned %v, want FailedPrecondition", err)
+	}
+
+	// trusting the gateway with X-Forwarded-For makes the address its own
+	_, mod, _, gateway = startModerated(t, true)
+	moderator = open(t, mod, &pb.SubscribeRequest{})
+	client = open(t, gateway, &pb.SubscribeRequest{})
+	if err := ban(moderator, mod, client.id); err != nil {
+		t.Errorf("banning the address of a trusted gateway client: %v", err)
+	}
+}
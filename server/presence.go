@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const idleCheckInterval = 10 * time.Second
+
+// touch records activity of the session and reports whether it was idle.
+func (s *Session) touch() bool {
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+	return atomic.CompareAndSwapInt32(&s.idle, 1, 0)
+}
+
+func (s *Session) presence(state pb.Presence_State) *pb.Presence {
+	lastActive, _ := ptypes.TimestampProto(time.Unix(0, atomic.LoadInt64(&s.lastActive)))
+	return &pb.Presence{
+		Id:         s.Id,
+		User:       s.User,
+		State:      state,
+		LastActive: lastActive,
+	}
+}
+
+func presenceEvent(sess *Session, room string, state pb.Presence_State) *pb.Message {
+	return sess.attribute(&pb.Message{
+		Room:  room,
+		Event: &pb.Message_Presence{Presence: sess.presence(state)},
+	})
+}
+
+// presenceEvents builds one event per room in rooms. The events are notices
+// from the server, the session they are about is in the event itself.
+func presenceEvents(sess *Session, rooms []string, state pb.Presence_State) []*pb.Message {
+	events := make([]*pb.Message, 0, len(rooms))
+	for _, room := range rooms {
+		events = append(events, presenceEvent(sess, room, state))
+	}
+	return events
+}
+
+// announce queues a presence event for every room sess has joined.
+func (s *ChatServer) announce(sess *Session, state pb.Presence_State) {
+	s.m.RLock()
+	rooms := s.roomsOf(sess)
+	s.m.RUnlock()
+	for _, msg := range presenceEvents(sess, rooms, state) {
+		s.Broadcast <- msg
+	}
+}
+
+// checkIdle marks the sessions inactive for longer than IdleTimeout as idle
+// and tells their rooms. It runs on the Run goroutine.
+func (s *ChatServer) checkIdle() {
+	if s.IdleTimeout <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-s.IdleTimeout).UnixNano()
+	var events []*pb.Message
+	s.m.RLock()
+	for _, sess := range s.Gophers {
+		if atomic.LoadInt64(&sess.lastActive) > deadline {
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&sess.idle, 0, 1) {
+			events = append(events, presenceEvents(sess, s.roomsOf(sess), pb.Presence_IDLE)...)
+		}
+	}
+	s.m.RUnlock()
+	for _, msg := range events {
+		s.deliver(nil, msg)
+		s.publish(msg)
+	}
+}
+
+func (s *ChatServer) ListOnline(ctx context.Context, req *pb.ListOnlineRequest) (*pb.OnlineList, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	sessions := s.Gophers
+	if req.Room != "" {
+		room, ok := s.Rooms[req.Room]
+		if !ok {
+			return nil, ErrRoomNotFound
+		}
+		sessions = room.members
+	}
+	list := &pb.OnlineList{}
+	for _, sess := range sessions {
+		state := pb.Presence_ACTIVE
+		if atomic.LoadInt32(&sess.idle) == 1 {
+			state = pb.Presence_IDLE
+		}
+		p := sess.presence(state)
+		p.Rooms = s.roomsOf(sess)
+		list.Online = append(list.Online, p)
+	}
+	sort.Slice(list.Online, func(i, j int) bool {
+		return list.Online[i].Id < list.Online[j].Id
+	})
+	return list, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxNicknameLength = 32
+
+var (
+	ErrInvalidNickname = status.Error(codes.InvalidArgument, "[profile] nickname must be 1 to 32 printable characters")
+	ErrNicknameTaken   = status.Error(codes.AlreadyExists, "[profile] nickname is taken")
+	ErrInvalidAvatar   = status.Error(codes.InvalidArgument, "[profile] avatar url must be an absolute http or https url")
+	ErrProfileNotFound = status.Error(codes.NotFound, "[profile] session is not online")
+)
+
+func (s *Session) profile() *pb.Profile {
+	s.RLock()
+	defer s.RUnlock()
+	return &pb.Profile{
+		Id:        s.Id,
+		Nickname:  s.nickname,
+		AvatarUrl: s.avatarURL,
+		Role:      s.role,
+	}
+}
+
+// attribute fills in the display name and avatar of msg from the profile of
+// sess, the display name being its id until a nickname is set.
+func (s *Session) attribute(msg *pb.Message) *pb.Message {
+	p := s.profile()
+	msg.DisplayName = p.Nickname
+	if msg.DisplayName == "" {
+		msg.DisplayName = p.Id
+	}
+	msg.AvatarUrl = p.AvatarUrl
+	return msg
+}
+
+// validName reports whether name is 1 to max printable characters, which
+// is what nicknames and room names have to be.
+func validName(name string, max int) bool {
+	if name == "" || utf8.RuneCountInString(name) > max {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validHTTPURL(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// nicknameKey makes nicknames differing only in case collide.
+func nicknameKey(nickname string) string {
+	return strings.ToLower(nickname)
+}
+
+// releaseNickname frees the nickname of sess. It must be called with s.m
+// held.
+func (s *ChatServer) releaseNickname(sess *Session) {
+	key := nicknameKey(sess.profile().Nickname)
+	if s.nicknames[key] == sess {
+		delete(s.nicknames, key)
+	}
+}
+
+func (s *ChatServer) SetNickname(ctx context.Context, req *pb.SetNicknameRequest) (*pb.Profile, error) {
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVoice(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	nickname := strings.TrimSpace(req.Nickname)
+	if !validName(nickname, MaxNicknameLength) {
+		return nil, ErrInvalidNickname
+	}
+	if req.AvatarUrl != "" && !validHTTPURL(req.AvatarUrl) {
+		return nil, ErrInvalidAvatar
+	}
+
+	s.m.Lock()
+	if s.Gophers[sess.Id] != sess {
+		s.m.Unlock()
+		return nil, ErrProfileNotFound
+	}
+	if other, ok := s.nicknames[nicknameKey(nickname)]; ok && other != sess {
+		s.m.Unlock()
+		return nil, ErrNicknameTaken
+	}
+	s.releaseNickname(sess)
+	s.nicknames[nicknameKey(nickname)] = sess
+	sess.Lock()
+	old := sess.nickname
+	sess.nickname, sess.avatarURL = nickname, req.AvatarUrl
+	sess.Unlock()
+	s.m.Unlock()
+
+	if old != nickname {
+		s.Broadcast <- sess.attribute(&pb.Message{
+			Event: &pb.Message_NicknameChange{NicknameChange: &pb.NicknameChange{
+				Id:          sess.Id,
+				OldNickname: old,
+				Nickname:    nickname,
+			}},
+		})
+	}
+	return sess.profile(), nil
+}
+
+func (s *ChatServer) GetProfile(ctx context.Context, req *pb.ProfileRequest) (*pb.Profile, error) {
+	sess, err := s.SessionByID(req.Id)
+	if err != nil {
+		return nil, ErrProfileNotFound
+	}
+	return sess.profile(), nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryAfterKey is the trailer telling a rate limited caller how many
+// seconds to wait; through the gateway it arrives as Grpc-Trailer-Retry-After.
+const RetryAfterKey = "retry-after"
+
+// Only sessions are limited by default. Limits per address are left to be
+// enabled where the server sees the addresses of its clients: behind the
+// gateway every client has the address of the gateway, unless the server
+// trusts its X-Forwarded-For.
+const (
+	DefaultSessionRate  = 5
+	DefaultSessionBurst = 10
+	DefaultPeerBurst    = 40
+)
+
+var ErrTooManyStreams = status.Error(codes.ResourceExhausted, "[ratelimit] too many streams from this address")
+
+// bucket is a token bucket holding up to burst tokens, refilled at rate
+// tokens per second.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter keeps a token bucket for every key it sees, each allowing
+// Burst events at once and Rate events per second on average.
+type RateLimiter struct {
+	Rate  float64
+	Burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key, or returns how long it takes
+// until one is there.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune forgets the buckets that have filled up again, which are no
+// different from new ones.
+func (l *RateLimiter) prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP is the address the call came from, without the port. With
+// TrustForwardedFor it is the client the gateway saw, which the gateway
+// appends to X-Forwarded-For.
+func (s *ChatServer) clientIP(ctx context.Context) string {
+	if s.TrustForwardedFor {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if fwd := md.Get("x-forwarded-for"); len(fwd) > 0 {
+				hops := strings.Split(fwd[len(fwd)-1], ",")
+				return strings.TrimSpace(hops[len(hops)-1])
+			}
+		}
+	}
+	addr := peerAddr(ctx)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// proxied reports whether a call came through a gateway: one presenting a
+// certificate of GatewayIdentities, or any caller setting X-Forwarded-For as
+// the gateway does.
+func (s *ChatServer) proxied(ctx context.Context) bool {
+	if cn, ok := certIdentity(ctx); ok && s.GatewayIdentities[cn] {
+		return true
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get("x-forwarded-for")) > 0
+}
+
+// checkRate takes a token for sess and one for its address, refusing the
+// call with ResourceExhausted and a retry-after trailer when either is out.
+// Every call that makes the server broadcast takes one. The frames of a chat
+// stream have already paid in readFrames, whatever call they stand for.
+func (s *ChatServer) checkRate(ctx context.Context, sess *Session) error {
+	if _, chat := ctx.Value(sessionKey{}).(*Session); chat {
+		return nil
+	}
+	return s.takeToken(ctx, sess)
+}
+
+// takeToken is checkRate for every call and chat frame alike.
+func (s *ChatServer) takeToken(ctx context.Context, sess *Session) error {
+	var wait time.Duration
+	if s.SessionLimit != nil {
+		if ok, d := s.SessionLimit.Allow(sess.Id); !ok {
+			wait = d
+		}
+	}
+	if s.PeerLimit != nil && wait == 0 {
+		if ok, d := s.PeerLimit.Allow(s.clientIP(ctx)); !ok {
+			wait = d
+		}
+	}
+	if wait == 0 {
+		return nil
+	}
+	atomic.AddUint64(&s.metrics.limited, 1)
+	seconds := int(math.Ceil(wait.Seconds()))
+	grpc.SetTrailer(ctx, metadata.Pairs(RetryAfterKey, fmt.Sprint(seconds)))
+	return status.Errorf(codes.ResourceExhausted, "[ratelimit] too many calls, retry after %ds", seconds)
+}
+
+// acquireStream counts a Subscribe or Chat stream against the cap of its
+// address. The returned func releases it.
+func (s *ChatServer) acquireStream(ctx context.Context) (func(), error) {
+	if s.MaxStreamsPerIP <= 0 {
+		return func() {}, nil
+	}
+	ip := s.clientIP(ctx)
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.streams[ip] >= s.MaxStreamsPerIP {
+		return nil, ErrTooManyStreams
+	}
+	s.streams[ip]++
+	return func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+		if s.streams[ip]--; s.streams[ip] == 0 {
+			delete(s.streams, ip)
+		}
+	}, nil
+}
+
+func (s *ChatServer) pruneLimits() {
+	if s.SessionLimit != nil {
+		s.SessionLimit.prune()
+	}
+	if s.PeerLimit != nil {
+		s.PeerLimit.prune()
+	}
+}
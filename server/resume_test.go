@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testSession is a Subscribe stream with the context its calls carry the
+// session token in.
+type testSession struct {
+	id     string
+	ctx    context.Context
+	stream pb.ChatService_SubscribeClient
+}
+
+// open subscribes to the lobby with req and returns the session.
+func open(t *testing.T, client pb.ChatServiceClient, req *pb.SubscribeRequest) *testSession {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := client.Subscribe(ctx, req)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	ids, tokens := header.Get(SessionIdKey), header.Get(SessionTokenKey)
+	if len(ids) == 0 || len(tokens) == 0 {
+		_, err := stream.Recv()
+		t.Fatalf("Subscribe: no session: %v", err)
+	}
+	return &testSession{
+		id:     ids[0],
+		ctx:    metadata.AppendToOutgoingContext(ctx, SessionTokenKey, tokens[0]),
+		stream: stream,
+	}
+}
+
+// send sends text to the lobby as sess.
+func (sess *testSession) send(client pb.ChatServiceClient, text string) error {
+	ctx, cancel := context.WithTimeout(sess.ctx, 2*time.Second)
+	defer cancel()
+	_, err := client.Send(ctx, &pb.Message{Id: sess.id, Text: text})
+	return err
+}
+
+// recvUntil reads the stored messages of stream up to seq, failing on a
+// gap or a duplicate after from.
+func recvUntil(t *testing.T, stream pb.ChatService_SubscribeClient, from, seq uint64) {
+	t.Helper()
+	for last := from; last < seq; {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv after seq %d: %v", last, err)
+		}
+		if msg.Seq == 0 {
+			continue
+		}
+		if msg.Seq != last+1 {
+			t.Fatalf("got seq %d after %d", msg.Seq, last)
+		}
+		last = msg.Seq
+	}
+}
+
+func TestResume(t *testing.T) {
+	addr := startServer(t, func(s *ChatServer) { s.SessionLimit = nil })
+	client, err := dial(t, addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := open(t, client, &pb.SubscribeRequest{})
+	for _, text := range []string{"one", "two", "three"} {
+		if err := sender.send(client, text); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	recvUntil(t, sender.stream, 0, 3)
+
+	// messages keep coming while the resumed session replays, some of them
+	// both stored before the replay reads the store and queued after it
+	// joined
+	const live = 50
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < live; i++ {
+			if err := sender.send(client, "live"); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	resumed := open(t, client, &pb.SubscribeRequest{ResumeFrom: 1})
+	recvUntil(t, resumed.stream, 1, 3+live)
+	if err := <-done; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+}
+
+func TestResumeEvicted(t *testing.T) {
+	addr := startServer(t, func(s *ChatServer) {
+		s.SessionLimit = nil
+		s.Store = NewMemoryStore(2)
+	})
+	client, err := dial(t, addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := open(t, client, &pb.SubscribeRequest{})
+	for _, text := range []string{"one", "two", "three", "four"} {
+		if err := sender.send(client, text); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	recvUntil(t, sender.stream, 0, 4)
+
+	// seq 2 fell out of the store holding 3 and 4
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	stream, err := client.Subscribe(ctx, &pb.SubscribeRequest{ResumeFrom: 1})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.OutOfRange {
+		t.Errorf("resuming from an evicted seq returned %v, want OutOfRange", err)
+	}
+
+	// the latest retained message is still fine to resume from
+	resumed := open(t, client, &pb.SubscribeRequest{ResumeFrom: 3})
+	recvUntil(t, resumed.stream, 3, 4)
+}
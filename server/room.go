@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+)
+
+const (
+	DefaultRoom       = "lobby"
+	MaxRoomNameLength = 32
+	DefaultMaxRooms   = 100
+)
+
+type Room struct {
+	Name    string
+	members map[string]*Session
+}
+
+var (
+	ErrRoomNotFound    = status.Error(codes.NotFound, "[room] room not found")
+	ErrRoomExists      = status.Error(codes.AlreadyExists, "[room] room already exists")
+	ErrInvalidRoomName = status.Error(codes.InvalidArgument, "[room] room name must be 1 to 32 printable characters")
+	ErrTooManyRooms    = status.Error(codes.ResourceExhausted, "[room] the server has reached its room limit")
+	ErrNotRoomMember   = status.Error(codes.PermissionDenied, "[room] session is not a member of the room")
+)
+
+func NewRoom(name string) *Room {
+	return &Room{
+		Name:    name,
+		members: make(map[string]*Session),
+	}
+}
+
+func roomName(name string) string {
+	if name == "" {
+		return DefaultRoom
+	}
+	return name
+}
+
+func (s *ChatServer) RoomByName(name string) (*Room, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	room, ok := s.Rooms[name]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return room, nil
+}
+
+// roomOf returns the named room if sess has joined it.
+func (s *ChatServer) roomOf(sess *Session, name string) (*Room, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	room, ok := s.Rooms[name]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	if _, ok := room.members[sess.Id]; !ok {
+		return nil, ErrNotRoomMember
+	}
+	return room, nil
+}
+
+func (s *ChatServer) join(sess *Session, name string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	room, ok := s.Rooms[name]
+	if !ok {
+		return ErrRoomNotFound
+	}
+	room.members[sess.Id] = sess
+	return nil
+}
+
+func (s *ChatServer) leave(sess *Session, name string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	room, ok := s.Rooms[name]
+	if !ok {
+		return ErrRoomNotFound
+	}
+	delete(room.members, sess.Id)
+	return nil
+}
+
+// leaveAll returns the names of the rooms sess left. It must be called with
+// s.m held.
+func (s *ChatServer) leaveAll(sess *Session) []string {
+	rooms := s.roomsOf(sess)
+	for _, name := range rooms {
+		delete(s.Rooms[name].members, sess.Id)
+	}
+	return rooms
+}
+
+// roomsOf returns the sorted names of the rooms sess has joined. It must be
+// called with s.m held.
+func (s *ChatServer) roomsOf(sess *Session) []string {
+	var rooms []string
+	for name, room := range s.Rooms {
+		if _, ok := room.members[sess.Id]; ok {
+			rooms = append(rooms, name)
+		}
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// CreateRoom adds a room for sessions to join. Rooms are never removed, so
+// there are at most MaxRooms of them.
+func (s *ChatServer) CreateRoom(ctx context.Context, req *pb.Room) (*pb.Room, error) {
+	sess, err := s.authorize(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	if !validName(req.Name, MaxRoomNameLength) {
+		return nil, ErrInvalidRoomName
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if _, ok := s.Rooms[req.Name]; ok {
+		return nil, ErrRoomExists
+	}
+	if s.MaxRooms > 0 && len(s.Rooms) >= s.MaxRooms {
+		return nil, ErrTooManyRooms
+	}
+	s.Rooms[req.Name] = NewRoom(req.Name)
+	s.logger(sess).Info("room created", "event", "room", "room", req.Name)
+	return &pb.Room{Name: req.Name}, nil
+}
+
+func (s *ChatServer) ListRooms(ctx context.Context, e *empty.Empty) (*pb.RoomList, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	list := &pb.RoomList{}
+	for _, room := range s.Rooms {
+		list.Rooms = append(list.Rooms, &pb.Room{
+			Name:    room.Name,
+			Members: int32(len(room.members)),
+		})
+	}
+	sort.Slice(list.Rooms, func(i, j int) bool {
+		return list.Rooms[i].Name < list.Rooms[j].Name
+	})
+	return list, nil
+}
+
+func (s *ChatServer) JoinRoom(ctx context.Context, req *pb.RoomRequest) (*empty.Empty, error) {
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBanned(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.join(sess, roomName(req.Room)); err != nil {
+		return nil, err
+	}
+	s.Broadcast <- presenceEvent(sess, roomName(req.Room), pb.Presence_JOINED)
+	return &empty.Empty{}, nil
+}
+
+func (s *ChatServer) LeaveRoom(ctx context.Context, req *pb.RoomRequest) (*empty.Empty, error) {
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.leave(sess, roomName(req.Room)); err != nil {
+		return nil, err
+	}
+	s.Broadcast <- presenceEvent(sess, roomName(req.Room), pb.Presence_LEFT)
+	return &empty.Empty{}, nil
+}
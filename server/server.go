@@ -3,14 +3,16 @@ package main
 import (
 	"context"
 	"errors"
-	"flag"
-	"fmt"
 	"github.com/golang/protobuf/ptypes/empty"
-	"google.golang.org/grpc"
-	"log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
 	"math/rand"
-	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/riimi/tutorial-grpc-chat/pb"
 )
@@ -20,55 +22,198 @@ import (
 type ChatServer struct {
 	Ctx        context.Context
 	Gophers    map[string]*Session
+	Rooms      map[string]*Room
+	Store      MessageStore
+	tokens     map[string]*Session
+	nicknames  map[string]*Session
 	m          sync.RWMutex
 	Broadcast  chan *pb.Message
 	Connect    chan *Session
 	Disconnect chan *Session
 
-	ErrorHandler func(*Session, error)
-	LogHandler   func(*Session, string)
+	SlowConsumer        SlowConsumerPolicy
+	SlowConsumerTimeout time.Duration
+	ShutdownMessage     string
+	// IdleTimeout is the inactivity after which a session is announced as
+	// idle, 0 to never.
+	IdleTimeout time.Duration
+	// TypingTimeout is how long a typing indicator lasts unless refreshed.
+	TypingTimeout time.Duration
+	// MaxRooms caps how many rooms CreateRoom lets exist, 0 for no cap.
+	MaxRooms int
+	// CertIdentity uses the subject of a verified client certificate as the
+	// session id instead of a random one, except for the certificates of
+	// GatewayIdentities: a gateway connects for many clients.
+	CertIdentity      bool
+	GatewayIdentities map[string]bool
+	// Authenticator enables Login, which hands out access tokens signed by
+	// Tokens. Calls are only checked when the Auth interceptors are installed.
+	Authenticator Authenticator
+	Tokens        *TokenIssuer
+	// Blobs stores uploaded attachments of up to MaxAttachmentSize bytes,
+	// nil to refuse uploads.
+	Blobs             BlobStore
+	MaxAttachmentSize int64
+	// SessionLimit and PeerLimit bound how fast a session and an address
+	// may make calls that broadcast, MaxStreamsPerIP how many sessions an
+	// address may hold open. Zero values disable them; only SessionLimit is
+	// set by default. TrustForwardedFor takes the address from the
+	// X-Forwarded-For of the gateway rather than the connection.
+	SessionLimit      *RateLimiter
+	PeerLimit         *RateLimiter
+	MaxStreamsPerIP   int
+	TrustForwardedFor bool
+	streams           map[string]int
+	// Filters check and rewrite what clients send before it is broadcast.
+	Filters FilterChain
+	// Roles maps user names, or certificate identities, to their role,
+	// Bans keeps banned identities and addresses out.
+	Roles map[string]pb.Role
+	Bans  *BanList
+	mutes map[string]time.Time
+	evict chan eviction
+
+	health *health.Server
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	draining bool
+	// broker carries messages to and from the other nodes, remote is where
+	// Run picks up the ones published elsewhere.
+	broker Broker
+	remote chan *pb.Message
+	// metrics is served by MetricsHandler.
+	metrics *Metrics
+
+	// Logger receives the records of the server, the session ones carrying
+	// its id and peer address.
+	Logger *slog.Logger
 }
 
 var (
 	ErrNotValidSession = errors.New("[broadcast] not valid session")
+	ErrResumeGap       = status.Error(codes.OutOfRange, "[subscribe] messages after resume_from are not retained")
 )
 
 func (s *ChatServer) Run(ctx context.Context) {
+	idle := time.NewTicker(idleCheckInterval)
+	defer idle.Stop()
+	typing := time.NewTicker(typingCheckInterval)
+	defer typing.Stop()
 	for {
 		select {
 		case msg := <-s.Broadcast:
-			sender, err := s.SessionByID(msg.Id)
-			if err != nil {
-				s.ErrorHandler(sender, err)
+			// messages without an id are notices from the server itself
+			var sender *Session
+			if msg.Id != "" {
+				var err error
+				if sender, err = s.SessionByID(msg.Id); err != nil {
+					s.reportError(sender, "broadcast", err)
+					continue
+				}
 			}
-			s.m.RLock()
-			for _, sess := range s.Gophers {
-				sess.writeMessage(msg)
+			s.deliver(sender, msg)
+			if shared(msg) {
+				s.publish(msg)
 			}
-			s.m.RUnlock()
-			s.LogHandler(sender, fmt.Sprintf("[broadcast] %v", *msg))
+		case msg := <-s.remote:
+			s.deliver(nil, msg)
+		case <-idle.C:
+			s.checkIdle()
+			s.pruneLimits()
+			s.pruneMutes()
+		case <-typing.C:
+			s.checkTyping()
 		case sess := <-s.Connect:
-			s.LogHandler(sess, "[connect]")
+			if sess.Id != "" {
+				if old, err := s.SessionByID(sess.Id); err == nil {
+					s.disconnect(old, ErrReplaced)
+				}
+			}
 			s.m.Lock()
-			sess.Id = s.generateRandomId(16)
+			if sess.Id == "" {
+				sess.Id = s.generateRandomId(16)
+			}
+			sess.token = generateToken()
 			s.Gophers[sess.Id] = sess
+			s.tokens[sess.token] = sess
 			s.m.Unlock()
+			s.logger(sess).Info("connect", "event", "connect")
 			sess.sync <- sess.Id
 		case sess := <-s.Disconnect:
-			s.LogHandler(sess, "[disconnect]")
-			if _, ok := s.Gophers[sess.Id]; ok {
-				s.m.Lock()
-				delete(s.Gophers, sess.Id)
-				s.m.Unlock()
-				sess.close()
+			s.disconnect(sess, nil)
+		case e := <-s.evict:
+			for _, msg := range e.events {
+				s.deliver(nil, msg)
+				s.publish(msg)
 			}
+			s.disconnect(e.sess, e.err)
 		case <-ctx.Done():
-			s.LogHandler(nil, "[terminate]")
+			s.Logger.Info("run loop stopped", "event", "terminate")
 			return
 		}
 	}
 }
 
+// deliver stores msg and writes it to its audience on this node. sender is
+// nil for notices and messages from other nodes. It runs on the Run goroutine.
+func (s *ChatServer) deliver(sender *Session, msg *pb.Message) {
+	start := time.Now()
+	if msg.Schema == pb.SchemaVersion_SCHEMA_UNKNOWN {
+		msg.Schema = CurrentSchema
+	}
+	if persistent(msg) {
+		if _, err := s.Store.Append(msg); err != nil {
+			s.reportError(sender, "store", err)
+		}
+	} else if err := s.applyChange(msg); err != nil {
+		s.reportError(sender, "store", err)
+	}
+	var slow []*Session
+	s.m.RLock()
+	for _, sess := range s.audience(msg) {
+		switch err := sess.writeMessage(msg); err {
+		case nil, ErrAlreadyClosed:
+		case ErrSlowConsumer:
+			slow = append(slow, sess)
+		default:
+			s.reportError(sess, "broadcast", err)
+		}
+	}
+	s.m.RUnlock()
+	s.metrics.observeFanout(time.Since(start))
+	atomic.AddUint64(&s.metrics.sent, 1)
+	for _, sess := range slow {
+		s.disconnect(sess, ErrSlowConsumer)
+	}
+	s.logger(sender).Debug("broadcast", "event", "broadcast", "room", msg.Room, "seq", msg.Seq, "recipients", len(msg.Recipients))
+}
+
+// disconnect removes sess from the server and closes it, err being what its
+// Subscribe call returns.
+func (s *ChatServer) disconnect(sess *Session, err error) {
+	s.m.Lock()
+	if cur, ok := s.Gophers[sess.Id]; !ok || cur != sess {
+		s.m.Unlock()
+		return
+	}
+	delete(s.Gophers, sess.Id)
+	delete(s.tokens, sess.token)
+	s.releaseNickname(sess)
+	rooms := s.leaveAll(sess)
+	s.m.Unlock()
+	if err != nil {
+		s.logger(sess).Info("disconnect", "event", "disconnect", "reason", err)
+	} else {
+		s.logger(sess).Info("disconnect", "event", "disconnect")
+	}
+	sess.closeWithError(err)
+	for _, msg := range presenceEvents(sess, rooms, pb.Presence_LEFT) {
+		s.deliver(nil, msg)
+		s.publish(msg)
+	}
+}
+
 func (s *ChatServer) generateRandomId(n int) string {
 	const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	b := make([]byte, n)
@@ -84,29 +229,144 @@ func (s *ChatServer) generateRandomId(n int) string {
 }
 
 func (s *ChatServer) Send(ctx context.Context, msg *pb.Message) (*empty.Empty, error) {
-	s.Broadcast <- msg
+	if s.closing() {
+		return nil, ErrShuttingDown
+	}
+	sender, err := s.authorize(ctx, msg.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVoice(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := checkContent(msg); err != nil {
+		return nil, err
+	}
+	if err := s.resolveAttachment(msg); err != nil {
+		return nil, err
+	}
+	if err := s.Filters.Filter(msg); err != nil {
+		return nil, err
+	}
+	if sender.touch() {
+		s.announce(sender, pb.Presence_ACTIVE)
+	}
+	if len(msg.Recipients) > 0 {
+		return s.sendDirect(sender, msg)
+	}
+	room, err := s.roomOf(sender, roomName(msg.Room))
+	if err != nil {
+		return nil, err
+	}
+	s.stopTyping(sender, room.Name)
+	author, _ := s.identity(sender)
+	s.Broadcast <- sender.attribute(&pb.Message{
+		Id:        sender.Id,
+		Text:      fallbackText(msg),
+		Room:      room.Name,
+		MessageId: generateMessageId(),
+		Content:   msg.Content,
+		Author:    author,
+	})
 	return &empty.Empty{}, nil
 }
 
-func (s *ChatServer) Subscribe(e *empty.Empty, stream pb.ChatService_SubscribeServer) error {
+func (s *ChatServer) Subscribe(req *pb.SubscribeRequest, stream pb.ChatService_SubscribeServer) error {
+	return s.serve(req, stream, (*Session).writePump)
+}
+
+// serve opens a session writing to stream, joins it to the room of req and
+// replays what it missed, then runs it with run until run returns.
+func (s *ChatServer) serve(req *pb.SubscribeRequest, stream messageStream, run func(*Session) error) error {
+	if s.closing() {
+		return ErrShuttingDown
+	}
+	room := roomName(req.Room)
+	if _, err := s.RoomByName(room); err != nil {
+		return err
+	}
+	release, err := s.acquireStream(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+	if req.ResumeFrom > 0 {
+		if err := s.checkResume(req.ResumeFrom); err != nil {
+			return err
+		}
+	}
 	sess := &Session{
 		app:    s,
+		peer:   peerAddr(stream.Context()),
 		output: make(chan *pb.Message, 32),
 		sync:   make(chan interface{}),
 		open:   true,
 	}
-	s.Connect <- sess
+	sess.touch()
+	sess.Id, sess.certified = s.identityFromCert(stream.Context())
+	sess.User, _ = UserFromContext(stream.Context())
+	if err := s.checkBanned(stream.Context(), sess); err != nil {
+		return err
+	}
+	sess.address = s.clientIP(stream.Context())
+	sess.sharedAddress = s.proxied(stream.Context()) && !s.TrustForwardedFor
+	sess.role = s.roleOf(sess)
+	select {
+	case s.Connect <- sess:
+	case <-s.Ctx.Done():
+		return ErrShuttingDown
+	}
 	defer func() {
-		s.Disconnect <- sess
+		select {
+		case s.Disconnect <- sess:
+		case <-s.Ctx.Done():
+		}
 	}()
 	<-sess.sync
 	sess.stream = stream
-	s.Broadcast <- &pb.Message{
-		Id:   sess.Id,
-		Text: "New Gopher!!",
+	if err := stream.SendHeader(metadata.Pairs(
+		SessionIdKey, sess.Id,
+		SessionTokenKey, sess.token,
+	)); err != nil {
+		return err
+	}
+	if err := s.join(sess, room); err != nil {
+		return err
+	}
+	if req.ResumeFrom > 0 {
+		if err := s.resume(sess, room, req.ResumeFrom); err != nil {
+			return err
+		}
 	}
+	s.Broadcast <- presenceEvent(sess, room, pb.Presence_JOINED)
+
+	return run(sess)
+}
 
-	return sess.writePump()
+// checkResume reports whether every message after seq is still in the store.
+func (s *ChatServer) checkResume(seq uint64) error {
+	first, last := s.Store.Bounds()
+	if seq > last || first > seq+1 {
+		return ErrResumeGap
+	}
+	return nil
+}
+
+// resume replays the stored messages of room after seq to a session that has
+// already joined it, so that nothing is lost between the replay and the live
+// messages queued in the meantime.
+func (s *ChatServer) resume(sess *Session, room string, seq uint64) error {
+	stored, err := s.Store.Range(Query{Room: room, After: seq})
+	if err != nil {
+		return err
+	}
+	if err := s.checkResume(seq); err != nil {
+		return err
+	}
+	return sess.replay(stored)
 }
 
 func (s *ChatServer) SessionByID(id string) (*Session, error) {
@@ -119,47 +379,42 @@ func (s *ChatServer) SessionByID(id string) (*Session, error) {
 	return sess, nil
 }
 
-func main() {
-	port := flag.Int("port", 40040, "port")
-	flag.Parse()
-	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", *port))
-	if err != nil {
-		log.Fatalf("[main] failed to listen: %v", err)
-	}
-
-	var opt []grpc.ServerOption
-	server := grpc.NewServer(opt...)
-	gs := NewServer()
-	gs.ErrorHandler = func(sess *Session, err error) {
-		if sess != nil {
-			log.Print(*sess)
-		}
-		log.Printf("%v\n", err)
-	}
-	gs.LogHandler = func(sess *Session, msg string) {
-		if sess != nil {
-			log.Printf("SessionId(%s) ", sess.Id)
-		}
-		log.Print(msg)
-	}
-	pb.RegisterChatServiceServer(server, gs)
-	log.Printf("[main] server is running at port: %d", *port)
-	log.Fatal(server.Serve(lis))
-}
-
 func NewServer() *ChatServer {
 	server := &ChatServer{
 		Gophers:    make(map[string]*Session),
+		Rooms:      map[string]*Room{DefaultRoom: NewRoom(DefaultRoom)},
+		Store:      NewMemoryStore(1000),
+		tokens:     make(map[string]*Session),
+		streams:    make(map[string]int),
+		mutes:      make(map[string]time.Time),
+		evict:      make(chan eviction, 100),
+		Bans:       &BanList{},
+		nicknames:  make(map[string]*Session),
 		Broadcast:  make(chan *pb.Message, 100),
 		Connect:    make(chan *Session, 100),
 		Disconnect: make(chan *Session, 100),
-		Ctx:        context.Background(),
+		done:       make(chan struct{}),
+		broker:     LocalBroker{},
+		remote:     make(chan *pb.Message, 100),
+		metrics:    NewMetrics(),
 
-		ErrorHandler: func(*Session, error) {},
-		LogHandler:   func(*Session, string) {},
+		SlowConsumer:        DropNewest,
+		SlowConsumerTimeout: 100 * time.Millisecond,
+		ShutdownMessage:     "server is going down",
+		IdleTimeout:         5 * time.Minute,
+		TypingTimeout:       5 * time.Second,
+		MaxAttachmentSize:   DefaultMaxAttachmentSize,
+		MaxRooms:            DefaultMaxRooms,
+		SessionLimit:        NewRateLimiter(DefaultSessionRate, DefaultSessionBurst),
+
+		Logger: discardLogger(),
 	}
 
-	go server.Run(server.Ctx)
+	server.Ctx, server.cancel = context.WithCancel(context.Background())
+	go func() {
+		server.Run(server.Ctx)
+		close(server.done)
+	}()
 
 	return server
 }
@@ -1,19 +1,61 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/metadata"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Session struct {
 	sync.RWMutex
 	output chan *pb.Message
 	sync   chan interface{}
-	stream pb.ChatService_SubscribeServer
+	stream messageStream
 	Id     string
+	User   string
+	token  string
 	open   bool
 	app    *ChatServer
+	peer   string
+	// certified is set when Id is the identity of the client certificate.
+	certified bool
+	// address is the client address rate limits and bans apply to, role
+	// what the session may do to others. sharedAddress is set when address
+	// is that of a gateway, which all of its clients share.
+	address       string
+	sharedAddress bool
+	role          pb.Role
+
+	// lastSeq is the seq of the last message sent on stream, used to skip
+	// live messages that were already replayed.
+	lastSeq uint64
+	// dropped counts every message lost to the slow consumer policy, pending
+	// those the client has not been told about yet.
+	dropped uint64
+	pending uint64
+	// err is returned from writePump when the server closed the session.
+	err error
+	// lastActive is the time of the last Send in unix nanoseconds, idle is 1
+	// once the session was announced as idle.
+	lastActive int64
+	idle       int32
+	// nickname and avatarURL make up the profile of the session.
+	nickname  string
+	avatarURL string
+	// typing holds until when the session is typing in each room.
+	typing map[string]time.Time
+}
+
+// messageStream is the stream of a Subscribe or Chat call a session writes
+// its messages to.
+type messageStream interface {
+	Send(*pb.Message) error
+	SendHeader(metadata.MD) error
+	Context() context.Context
 }
 
 var (
@@ -27,6 +69,8 @@ func (s *Session) closed() bool {
 	return !s.open
 }
 
+// writeMessage queues msg for the client, applying the slow consumer policy
+// of the server when the output buffer is full.
 func (s *Session) writeMessage(msg *pb.Message) error {
 	if s.closed() {
 		return ErrAlreadyClosed
@@ -34,33 +78,116 @@ func (s *Session) writeMessage(msg *pb.Message) error {
 
 	select {
 	case s.output <- msg:
+		return nil
 	default:
-		return ErrWriteBufferFull
 	}
-	return nil
+
+	switch s.app.SlowConsumer {
+	case DropOldest:
+		select {
+		case <-s.output:
+			s.drop()
+		default:
+		}
+		select {
+		case s.output <- msg:
+			return nil
+		default:
+		}
+	case BlockWithTimeout:
+		timer := time.NewTimer(s.app.SlowConsumerTimeout)
+		defer timer.Stop()
+		select {
+		case s.output <- msg:
+			return nil
+		case <-timer.C:
+		}
+	case DisconnectSlow:
+		return ErrSlowConsumer
+	}
+	s.drop()
+	return ErrWriteBufferFull
+}
+
+func (s *Session) drop() {
+	atomic.AddUint64(&s.dropped, 1)
+	atomic.AddUint64(&s.pending, 1)
+	atomic.AddUint64(&s.app.metrics.dropped, 1)
+}
+
+// Dropped returns the number of messages the session lost so far.
+func (s *Session) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
 }
 
 func (s *Session) close() {
+	s.closeWithError(nil)
+}
+
+// closeWithError closes the session and makes writePump return err once the
+// queued messages are sent.
+func (s *Session) closeWithError(err error) {
 	if !s.closed() {
 		s.Lock()
 		s.open = false
+		s.err = err
 		close(s.output)
 		s.Unlock()
 	}
 }
 
+// writePump sends the queued messages to the client until the session is
+// closed or the client goes away.
 func (s *Session) writePump() error {
+	done := s.stream.Context().Done()
 	for {
 		select {
+		case <-done:
+			s.app.logger(s).Debug("client gone", "event", "writepump")
+			return s.stream.Context().Err()
 		case msg, more := <-s.output:
 			if !more {
-				s.app.LogHandler(s, "[session] writepump channel closed")
-				return nil
+				s.app.logger(s).Debug("output closed", "event", "writepump")
+				s.RLock()
+				defer s.RUnlock()
+				return s.err
+			}
+			if msg.Seq != 0 {
+				if msg.Seq <= s.lastSeq {
+					continue
+				}
+				s.lastSeq = msg.Seq
 			}
 			if err := s.stream.Send(msg); err != nil {
-				s.app.ErrorHandler(s, err)
+				s.app.reportError(s, "writepump", err)
 				return err
 			}
+			if len(s.output) == 0 {
+				if err := s.notifyDropped(); err != nil {
+					s.app.reportError(s, "writepump", err)
+					return err
+				}
+			}
+		}
+	}
+}
+
+// notifyDropped tells the client how many messages it lost since the last
+// notice, once it has caught up with its queue.
+func (s *Session) notifyDropped() error {
+	n := atomic.SwapUint64(&s.pending, 0)
+	if n == 0 {
+		return nil
+	}
+	return s.stream.Send(dropNotice(n))
+}
+
+func (s *Session) replay(stored []*StoredMessage) error {
+	for _, m := range stored {
+		if err := s.stream.Send(m.Msg); err != nil {
+			return err
 		}
+		s.lastSeq = m.Seq
 	}
+	return nil
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+var (
+	ErrShuttingDown = status.Error(codes.Unavailable, "[server] server is shutting down")
+)
+
+const drainPollInterval = 50 * time.Millisecond
+
+func (s *ChatServer) closing() bool {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.draining
+}
+
+// Shutdown stops accepting new sessions, tells every room that the server is
+// going down, waits for the queued messages to reach the clients, stops Run
+// and closes all sessions. Sessions are closed even when ctx expires before
+// their queues are drained.
+func (s *ChatServer) Shutdown(ctx context.Context) error {
+	s.m.Lock()
+	if s.draining {
+		s.m.Unlock()
+		return nil
+	}
+	s.draining = true
+	if s.health != nil {
+		// tells the orchestrator to route new clients elsewhere
+		s.health.Shutdown()
+	}
+	rooms := make([]string, 0, len(s.Rooms))
+	for name := range s.Rooms {
+		rooms = append(rooms, name)
+	}
+	s.m.Unlock()
+	s.Logger.Info("draining sessions", "event", "shutdown")
+
+	if s.ShutdownMessage != "" {
+		for _, room := range rooms {
+			s.Broadcast <- notice(room, pb.Notice_WARNING, s.ShutdownMessage)
+		}
+	}
+	drainErr := s.waitUntil(ctx, s.drained)
+
+	s.cancel()
+	<-s.done
+	// with Run gone nothing else disconnects sessions, so they are closed
+	// here whether or not ctx expired
+	s.m.RLock()
+	sessions := make([]*Session, 0, len(s.Gophers))
+	for _, sess := range s.Gophers {
+		sessions = append(sessions, sess)
+	}
+	s.m.RUnlock()
+	for _, sess := range sessions {
+		s.disconnect(sess, nil)
+	}
+
+	s.m.RLock()
+	brokerErr := s.broker.Close()
+	s.m.RUnlock()
+	storeErr := s.Store.Close()
+
+	for _, err := range []error{drainErr, brokerErr, storeErr} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drained reports whether every broadcast has been handed to the sessions and
+// every session has sent its queue to the client.
+func (s *ChatServer) drained() bool {
+	if len(s.Broadcast) > 0 {
+		return false
+	}
+	s.m.RLock()
+	defer s.m.RUnlock()
+	for _, sess := range s.Gophers {
+		if len(sess.output) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ChatServer) waitUntil(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
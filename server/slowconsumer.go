@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SlowConsumerPolicy decides what Session.writeMessage does when the output
+// buffer of a session is full.
+type SlowConsumerPolicy int
+
+const (
+	// DropNewest discards the message that does not fit.
+	DropNewest SlowConsumerPolicy = iota
+	// DropOldest discards the oldest queued message to make room.
+	DropOldest
+	// BlockWithTimeout waits up to ChatServer.SlowConsumerTimeout for room,
+	// holding up the broadcast to everyone else meanwhile, then drops.
+	BlockWithTimeout
+	// DisconnectSlow closes the session.
+	DisconnectSlow
+)
+
+var slowConsumerPolicyNames = map[SlowConsumerPolicy]string{
+	DropNewest:       "drop-newest",
+	DropOldest:       "drop-oldest",
+	BlockWithTimeout: "block",
+	DisconnectSlow:   "disconnect",
+}
+
+var (
+	ErrSlowConsumer = status.Error(codes.ResourceExhausted, "[session] disconnected for not keeping up with the messages")
+)
+
+func (p SlowConsumerPolicy) String() string {
+	if name, ok := slowConsumerPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("SlowConsumerPolicy(%d)", int(p))
+}
+
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	for p, n := range slowConsumerPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return DropNewest, fmt.Errorf("unknown slow consumer policy %q", name)
+}
+
+func dropNotice(n uint64) *pb.Message {
+	return notice("", pb.Notice_WARNING, fmt.Sprintf("%d message(s) were dropped because the connection could not keep up", n))
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 500
+)
+
+// MessageStore keeps the messages broadcast by ChatServer so that sessions
+// joining later can read what they missed.
+type MessageStore interface {
+	// Append records msg, stamping its Seq and Timestamp.
+	Append(msg *pb.Message) (*StoredMessage, error)
+	// Range returns the stored messages matching q, oldest first.
+	Range(q Query) ([]*StoredMessage, error)
+	// Lookup returns the stored message with the given MessageId.
+	Lookup(messageId string) (*StoredMessage, error)
+	// Update replaces the stored message with the MessageId of msg, which
+	// keeps the Seq and Timestamp of the original.
+	Update(msg *pb.Message) error
+	// Bounds returns the sequence numbers of the oldest retained and the
+	// newest appended message, or zeros when nothing was stored yet.
+	Bounds() (first, last uint64)
+	Close() error
+}
+
+type StoredMessage struct {
+	Seq  uint64
+	Time time.Time
+	Msg  *pb.Message
+}
+
+// Query selects stored messages of a room. Zero values leave a bound open.
+type Query struct {
+	Room   string
+	After  uint64 // Seq > After
+	Before uint64 // Seq < Before
+	Since  time.Time
+	Until  time.Time
+	// Limit keeps only the newest Limit matching messages.
+	Limit int
+}
+
+var (
+	ErrStoreClosed     = errors.New("[store] store is closed")
+	ErrMessageNotFound = status.Error(codes.NotFound, "[store] message is not in the history")
+)
+
+// persistent reports whether msg belongs in the room history: direct
+// messages and events are delivered but not stored.
+func persistent(msg *pb.Message) bool {
+	return len(msg.Recipients) == 0 && msg.Event == nil
+}
+
+func stamp(msg *pb.Message, seq uint64, t time.Time) *StoredMessage {
+	msg.Seq = seq
+	msg.Timestamp, _ = ptypes.TimestampProto(t)
+	return &StoredMessage{
+		Seq:  seq,
+		Time: t,
+		Msg:  msg,
+	}
+}
+
+func (q Query) match(seq uint64, t time.Time, room string) bool {
+	if room != q.Room {
+		return false
+	}
+	if seq <= q.After || (q.Before != 0 && seq >= q.Before) {
+		return false
+	}
+	if !q.Since.IsZero() && t.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !t.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// MemoryStore is a MessageStore holding the last Capacity messages in a ring.
+type MemoryStore struct {
+	sync.RWMutex
+	ring   []*StoredMessage
+	next   int
+	seq    uint64
+	closed bool
+}
+
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryStore{
+		ring: make([]*StoredMessage, 0, capacity),
+	}
+}
+
+func (m *MemoryStore) Append(msg *pb.Message) (*StoredMessage, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, ErrStoreClosed
+	}
+	m.seq++
+	stored := stamp(msg, m.seq, time.Now())
+	if len(m.ring) < cap(m.ring) {
+		m.ring = append(m.ring, stored)
+	} else {
+		m.ring[m.next] = stored
+		m.next = (m.next + 1) % len(m.ring)
+	}
+	return stored, nil
+}
+
+func (m *MemoryStore) Range(q Query) ([]*StoredMessage, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, ErrStoreClosed
+	}
+	var out []*StoredMessage
+	for i := range m.ring {
+		stored := m.ring[(m.next+i)%len(m.ring)]
+		if q.match(stored.Seq, stored.Time, stored.Msg.Room) {
+			out = append(out, stored)
+		}
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out, nil
+}
+
+func (m *MemoryStore) Lookup(messageId string) (*StoredMessage, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, ErrStoreClosed
+	}
+	if i := m.find(messageId); i >= 0 {
+		return m.ring[i], nil
+	}
+	return nil, ErrMessageNotFound
+}
+
+func (m *MemoryStore) Update(msg *pb.Message) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return ErrStoreClosed
+	}
+	i := m.find(msg.MessageId)
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	m.ring[i] = stamp(msg, m.ring[i].Seq, m.ring[i].Time)
+	return nil
+}
+
+// find returns the position of the message in the ring, or -1. It must be
+// called with m held.
+func (m *MemoryStore) find(messageId string) int {
+	if messageId == "" {
+		return -1
+	}
+	for i, stored := range m.ring {
+		if stored.Msg.MessageId == messageId {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MemoryStore) Bounds() (uint64, uint64) {
+	m.RLock()
+	defer m.RUnlock()
+	if len(m.ring) == 0 {
+		return 0, 0
+	}
+	return m.ring[m.next%len(m.ring)].Seq, m.seq
+}
+
+func (m *MemoryStore) Close() error {
+	m.Lock()
+	defer m.Unlock()
+	m.closed = true
+	return nil
+}
+
+func (s *ChatServer) History(ctx context.Context, req *pb.HistoryRequest) (*pb.HistoryResponse, error) {
+	sess, err := s.SessionFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	room, err := s.roomOf(sess, roomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	} else if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+	// fetch one extra message to learn whether an older page exists
+	stored, err := s.Store.Range(Query{
+		Room:   room.Name,
+		Before: req.Cursor,
+		Limit:  limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.HistoryResponse{}
+	if len(stored) > limit {
+		stored = stored[1:]
+		resp.NextCursor = stored[0].Seq
+	}
+	for _, m := range stored {
+		resp.Messages = append(resp.Messages, m.Msg)
+	}
+	return resp, nil
+}
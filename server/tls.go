@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"io/ioutil"
+)
+
+// serverTLSConfig loads the server certificate. When caFile is set, clients
+// have to present a certificate signed by one of its CAs.
+func serverTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// peerTLSConfig presents the server certificate to other nodes, which have
+// to be signed by one of the CAs in caFile when set.
+func peerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		if cfg.RootCAs, err = loadCertPool(caFile); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("[tls] no certificate found in " + file)
+	}
+	return pool, nil
+}
+
+// certIdentity returns the subject common name of the verified client
+// certificate the call was made with.
+func certIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", false
+	}
+	chains := info.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return "", false
+	}
+	cn := chains[0][0].Subject.CommonName
+	return cn, cn != ""
+}
+
+// identityFromCert returns the certificate identity of a call when sessions
+// are known by it: with CertIdentity, for certificates other than those of
+// GatewayIdentities.
+func (s *ChatServer) identityFromCert(ctx context.Context) (string, bool) {
+	if !s.CertIdentity {
+		return "", false
+	}
+	cn, ok := certIdentity(ctx)
+	if !ok || s.GatewayIdentities[cn] {
+		return "", false
+	}
+	return cn, true
+}
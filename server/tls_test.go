@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// testPKI is a throwaway CA with a server certificate for 127.0.0.1, which
+// also signs client certificates on demand.
+type testPKI struct {
+	dir    string
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+	pool   *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	p := &testPKI{dir: t.TempDir(), pool: x509.NewCertPool()}
+	p.caKey, p.caCert = issueCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	p.pool.AddCert(p.caCert)
+	p.write(t, "ca", p.caKey, p.caCert)
+	key, cert := issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "chatserver"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, p.caKey, p.caCert)
+	p.write(t, "server", key, cert)
+	return p
+}
+
+// issueCert signs tmpl with the parent key, or self-signs it when parent is
+// nil.
+func issueCert(t *testing.T, tmpl *x509.Certificate, parentKey *ecdsa.PrivateKey, parent *x509.Certificate) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+// write stores the PEM files serverTLSConfig loads, name.pem and
+// name-key.pem.
+func (p *testPKI) write(t *testing.T, name string, key *ecdsa.PrivateKey, cert *x509.Certificate) {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := ioutil.WriteFile(p.path(name+"-key"), keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := ioutil.WriteFile(p.path(name), certPem, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (p *testPKI) path(name string) string {
+	return filepath.Join(p.dir, name+".pem")
+}
+
+// clientCert issues a client certificate with common name cn.
+func (p *testPKI) clientCert(t *testing.T, cn string) tls.Certificate {
+	t.Helper()
+	key, cert := issueCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, p.caKey, p.caCert)
+	return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}
+}
+
+// clientCreds trusts the test CA and presents certs.
+func (p *testPKI) clientCreds(certs ...tls.Certificate) grpc.DialOption {
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      p.pool,
+		Certificates: certs,
+	}))
+}
+
+// startServer serves a new ChatServer on a free port, configured by
+// configure, and returns its address.
+func startServer(t *testing.T, configure func(*ChatServer), opt ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := NewServer()
+	if configure != nil {
+		configure(gs)
+	}
+	server := grpc.NewServer(opt...)
+	pb.RegisterChatServiceServer(server, gs)
+	go server.Serve(lis)
+	t.Cleanup(func() {
+		server.Stop()
+		gs.cancel()
+	})
+	return lis.Addr().String()
+}
+
+func serverCreds(t *testing.T, p *testPKI, caFile string) grpc.ServerOption {
+	t.Helper()
+	cfg, err := serverTLSConfig(p.path("server"), p.path("server-key"), caFile)
+	if err != nil {
+		t.Fatalf("serverTLSConfig: %v", err)
+	}
+	return grpc.Creds(credentials.NewTLS(cfg))
+}
+
+// dial connects to addr and makes a call, returning its error.
+func dial(t *testing.T, addr string, opt grpc.DialOption) (pb.ChatServiceClient, error) {
+	t.Helper()
+	conn, err := grpc.Dial(addr, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := pb.NewChatServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = client.ListRooms(ctx, &empty.Empty{})
+	return client, err
+}
+
+// subscribe opens a session and returns its id.
+func subscribe(t *testing.T, client pb.ChatServiceClient) string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := client.Subscribe(ctx, &pb.SubscribeRequest{})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	ids := header.Get(SessionIdKey)
+	if len(ids) == 0 {
+		_, err := stream.Recv()
+		t.Fatalf("Subscribe: no session: %v", err)
+	}
+	return ids[0]
+}
+
+func TestPlaintext(t *testing.T) {
+	addr := startServer(t, nil)
+	if _, err := dial(t, addr, grpc.WithInsecure()); err != nil {
+		t.Errorf("plaintext call failed: %v", err)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	p := newTestPKI(t)
+	addr := startServer(t, nil, serverCreds(t, p, ""))
+	if _, err := dial(t, addr, p.clientCreds()); err != nil {
+		t.Errorf("TLS call failed: %v", err)
+	}
+	if _, err := dial(t, addr, grpc.WithInsecure()); err == nil {
+		t.Error("plaintext call to a TLS server succeeded")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	p := newTestPKI(t)
+	addr := startServer(t, nil, serverCreds(t, p, p.path("ca")))
+	if _, err := dial(t, addr, p.clientCreds()); err == nil {
+		t.Error("call without a client certificate succeeded")
+	}
+	if _, err := dial(t, addr, p.clientCreds(p.clientCert(t, "gopher"))); err != nil {
+		t.Errorf("call with a client certificate failed: %v", err)
+	}
+
+	// a certificate from another CA is no better than none
+	other := newTestPKI(t)
+	if _, err := dial(t, addr, p.clientCreds(other.clientCert(t, "gopher"))); err == nil {
+		t.Error("call with a certificate of an unknown CA succeeded")
+	}
+}
+
+func TestCertIdentity(t *testing.T) {
+	p := newTestPKI(t)
+	addr := startServer(t, func(s *ChatServer) {
+		s.CertIdentity = true
+		s.GatewayIdentities = map[string]bool{"gateway": true}
+	}, serverCreds(t, p, p.path("ca")))
+
+	client, err := dial(t, addr, p.clientCreds(p.clientCert(t, "gopher")))
+	if err != nil {
+		t.Fatalf("call with a client certificate failed: %v", err)
+	}
+	if id := subscribe(t, client); id != "gopher" {
+		t.Errorf("session id is %q, want the common name gopher", id)
+	}
+
+	// every client of a gateway gets a session of its own
+	gateway, err := dial(t, addr, p.clientCreds(p.clientCert(t, "gateway")))
+	if err != nil {
+		t.Fatalf("call with the gateway certificate failed: %v", err)
+	}
+	first, second := subscribe(t, gateway), subscribe(t, gateway)
+	if first == "gateway" || second == "gateway" || first == second {
+		t.Errorf("gateway sessions got ids %q and %q, want two random ones", first, second)
+	}
+}
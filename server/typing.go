@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"time"
+)
+
+const typingCheckInterval = time.Second
+
+func typingEvent(sess *Session, room string, typing bool) *pb.Message {
+	return sess.attribute(&pb.Message{
+		Id:    sess.Id,
+		Room:  room,
+		Event: &pb.Message_Typing{Typing: &pb.Typing{Typing: typing}},
+	})
+}
+
+// setTyping records until when sess is typing in room, the zero time
+// meaning it stopped, and reports whether that changed whether it types.
+func (s *Session) setTyping(room string, until time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+	_, was := s.typing[room]
+	if until.IsZero() {
+		delete(s.typing, room)
+		return was
+	}
+	if s.typing == nil {
+		s.typing = make(map[string]time.Time)
+	}
+	s.typing[room] = until
+	return !was
+}
+
+// expireTyping stops the indicators of sess that were not refreshed in time
+// and returns their rooms.
+func (s *Session) expireTyping(now time.Time) []string {
+	s.Lock()
+	defer s.Unlock()
+	var rooms []string
+	for room, until := range s.typing {
+		if now.After(until) {
+			delete(s.typing, room)
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
+
+func (s *ChatServer) SetTyping(ctx context.Context, req *pb.TypingRequest) (*empty.Empty, error) {
+	if s.closing() {
+		return nil, ErrShuttingDown
+	}
+	sess, err := s.authorize(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkVoice(ctx, sess); err != nil {
+		return nil, err
+	}
+	if err := s.checkRate(ctx, sess); err != nil {
+		return nil, err
+	}
+	room, err := s.roomOf(sess, roomName(req.Room))
+	if err != nil {
+		return nil, err
+	}
+	var until time.Time
+	if req.Typing {
+		until = time.Now().Add(s.TypingTimeout)
+	}
+	if sess.setTyping(room.Name, until) {
+		s.Broadcast <- typingEvent(sess, room.Name, req.Typing)
+	}
+	return &empty.Empty{}, nil
+}
+
+// stopTyping ends the indicator of sess in room, when it is typing there,
+// before its message is broadcast.
+func (s *ChatServer) stopTyping(sess *Session, room string) {
+	if sess.setTyping(room, time.Time{}) {
+		s.Broadcast <- typingEvent(sess, room, false)
+	}
+}
+
+// checkTyping stops the indicators that were not refreshed within
+// TypingTimeout. It runs on the Run goroutine.
+func (s *ChatServer) checkTyping() {
+	now := time.Now()
+	var events []*pb.Message
+	s.m.RLock()
+	for _, sess := range s.Gophers {
+		for _, room := range sess.expireTyping(now) {
+			events = append(events, typingEvent(sess, room, false))
+		}
+	}
+	s.m.RUnlock()
+	for _, msg := range events {
+		s.deliver(nil, msg)
+		s.publish(msg)
+	}
+}
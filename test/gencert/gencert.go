@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// gencert writes a throwaway CA plus a server and a client certificate signed
+// by it, for trying out TLS and mutual TLS locally:
+//
+//	go run test/gencert/gencert.go -out certs -client gopher
+//	go run ./server -cert certs/server.pem -key certs/server-key.pem -ca certs/ca.pem -cert-identity
+//	go run test/tls-client/tls-client.go -ca certs/ca.pem -cert certs/client.pem -key certs/client-key.pem
+func main() {
+	out := flag.String("out", "certs", "output directory")
+	host := flag.String("host", "localhost,127.0.0.1", "comma separated host names and IPs of the server certificate")
+	client := flag.String("client", "gopher", "common name of the client certificate")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatal(err)
+	}
+	caKey, caCert := issue(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "tutorial-grpc-chat test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	write(*out, "ca", caKey, caCert)
+
+	// chat server nodes present their server certificate to each other
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "chatserver"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, h := range splitHosts(*host) {
+		if ip := net.ParseIP(h); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else {
+			server.DNSNames = append(server.DNSNames, h)
+		}
+	}
+	key, cert := issue(server, caKey, caCert)
+	write(*out, "server", key, cert)
+
+	key, cert = issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: *client},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caKey, caCert)
+	write(*out, "client", key, cert)
+	log.Printf("certificates written to %s", *out)
+}
+
+func splitHosts(s string) []string {
+	var hosts []string
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ',' {
+			if i > start {
+				hosts = append(hosts, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return hosts
+}
+
+// issue signs tmpl with the parent key, or self-signs it when parent is nil.
+func issue(tmpl *x509.Certificate, parentKey *ecdsa.PrivateKey, parent *x509.Certificate) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		log.Fatal(err)
+	}
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(30 * 24 * time.Hour)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return key, cert
+}
+
+func write(dir, name string, key *ecdsa.PrivateKey, cert *x509.Certificate) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPem, 0600); err != nil {
+		log.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), certPem, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
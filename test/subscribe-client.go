@@ -20,6 +20,9 @@ func main() {
 
 	res, _ := http.DefaultClient.Do(req)
 	defer res.Body.Close()
+	log.Printf("session id: %s, token: %s",
+		res.Header.Get("Grpc-Metadata-X-Session-Id"),
+		res.Header.Get("Grpc-Metadata-X-Session-Token"))
 	reader := bufio.NewReader(res.Body)
 	for {
 		line, err := reader.ReadBytes('\n')
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"github.com/riimi/tutorial-grpc-chat/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// tls-client subscribes over mutual TLS and sends one message without a
+// session token, relying on the server running with -cert-identity to
+// recognize it by its certificate. Certificates come from test/gencert.
+func main() {
+	addr := flag.String("addr", "localhost:40040", "grpc server address")
+	caFile := flag.String("ca", "certs/ca.pem", "CA file")
+	certFile := flag.String("cert", "certs/client.pem", "client certificate file")
+	keyFile := flag.String("key", "certs/client-key.pem", "client private key file")
+	flag.Parse()
+
+	pem, err := ioutil.ReadFile(*caFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pem)
+	cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	})
+	conn, err := grpc.Dial(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewChatServiceClient(conn)
+
+	stream, err := client.Subscribe(context.Background(), &pb.SubscribeRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("session id: %v", header.Get("x-session-id"))
+
+	if _, err := client.Send(context.Background(), &pb.Message{Text: "hello over mTLS"}); err != nil {
+		log.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		msg, err := stream.Recv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("id: %s, text: %s", msg.Id, msg.Text)
+	}
+}